@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EnvDir overrides the cache directory
+const EnvDir = "BRAINDUMP_CACHE_DIR"
+
+// Cache is an on-disk store of parsed session data.
+// Entries are JSON files grouped by namespace and addressed by a hash of their key,
+// so a single entry can be loaded without reading the rest of the cache.
+// It is safe for concurrent use as long as each key is written by one goroutine at a time.
+type Cache struct {
+	dir string
+}
+
+// DefaultDir returns the cache directory used when none is given:
+// $BRAINDUMP_CACHE_DIR, or braindump under the user cache directory (e.g. ~/.cache/braindump).
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(EnvDir); dir != "" {
+		return dir, nil
+	}
+
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(userCacheDir, "braindump"), nil
+}
+
+// New creates a cache rooted at dir. The directory is created on first write.
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Load decodes the entry stored under namespace and key into v.
+// It reports false if there is no usable entry.
+func (c *Cache) Load(namespace, key string, v any) bool {
+	data, err := os.ReadFile(c.path(namespace, key))
+	if err != nil {
+		return false
+	}
+
+	// A corrupt entry is treated as a miss and overwritten on the next Store
+	return json.Unmarshal(data, v) == nil
+}
+
+// Store writes v as the entry for namespace and key, replacing any previous entry atomically
+func (c *Cache) Store(namespace, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	path := c.path(namespace, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Clear removes every cache entry
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
+
+// path returns the file holding the entry for namespace and key
+func (c *Cache) path(namespace, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, namespace, name[:2], name+".json")
+}
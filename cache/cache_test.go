@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type entry struct {
+	Size  int64  `json:"size"`
+	Value string `json:"value"`
+}
+
+func TestStoreLoad(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "braindump"))
+
+	var got entry
+	if c.Load("test", "missing", &got) {
+		t.Fatal("Load: expected miss for missing key")
+	}
+
+	want := entry{Size: 42, Value: "hello"}
+	if err := c.Store("test", "/some/file.jsonl", want); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	if !c.Load("test", "/some/file.jsonl", &got) {
+		t.Fatal("Load: expected hit after Store")
+	}
+	if got != want {
+		t.Errorf("Load: got %+v, want %+v", got, want)
+	}
+
+	// Namespaces are independent
+	if c.Load("other", "/some/file.jsonl", &got) {
+		t.Error("Load: expected miss in a different namespace")
+	}
+}
+
+func TestLoadCorruptEntry(t *testing.T) {
+	c := New(t.TempDir())
+
+	path := c.path("test", "key")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got entry
+	if c.Load("test", "key", &got) {
+		t.Error("Load: expected miss for corrupt entry")
+	}
+}
+
+func TestClear(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "braindump"))
+
+	if err := c.Store("test", "key", entry{Value: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+
+	var got entry
+	if c.Load("test", "key", &got) {
+		t.Error("Load: expected miss after Clear")
+	}
+
+	// Clearing an absent cache is not an error
+	if err := c.Clear(); err != nil {
+		t.Errorf("Clear on empty cache: %v", err)
+	}
+}
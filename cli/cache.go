@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/block/braindump/cache"
+	"github.com/spf13/cobra"
+)
+
+// newCacheCmd creates the "cache" command for managing the parsed session cache
+func newCacheCmd() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the parsed session cache",
+		Long: `braindump caches parsed sessions (by default under ~/.cache/braindump,
+or $BRAINDUMP_CACHE_DIR) so unchanged files are not parsed again.`,
+	}
+
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached session data",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheClear,
+	})
+
+	return cacheCmd
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return err
+	}
+
+	if err := cache.New(dir).Clear(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(cmd.OutOrStdout(), "Cleared cache at %s\n", dir)
+	return err
+}
@@ -0,0 +1,195 @@
+// Package cli implements the braindump command line. Programs that read
+// their own agents' sessions build it with their sources added:
+//
+//	registry := cli.NewRegistry()
+//	registry.Register("myagent", myagent.New)
+//	cli.Main(registry)
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/block/braindump/internal/filter"
+	"github.com/block/braindump/internal/media"
+	"github.com/block/braindump/internal/output"
+	"github.com/block/braindump/model"
+	"github.com/block/braindump/source"
+	"github.com/spf13/cobra"
+)
+
+// Output formats accepted by --format
+const (
+	formatJSON     = "json"
+	formatNDJSON   = "ndjson"
+	formatSummary  = "summary"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+)
+
+var (
+	agentType string
+	sessionID string
+	since     string
+	until     string
+	outFile   string
+	format    string
+	pretty    bool
+	summary   bool
+	jobs      int
+	noCache   bool
+
+	includeThinking bool
+	stripThinking   bool
+	visibleTo       string
+	branch          string
+
+	extractMedia string
+
+	printDiagnostics bool
+	strict           bool
+
+	// sources are the agents the command reads, set by Main
+	sources *source.Registry
+)
+
+// Main runs the braindump command with the sources in registry and exits
+// with a non-zero status if it fails
+func Main(registry *source.Registry) {
+	sources = registry
+
+	var rootCmd = &cobra.Command{
+		Use:   "braindump",
+		Short: "Dump agent session histories to JSON",
+		Long: `braindump reads Claude Code and Goose AI agent session histories
+and outputs them in a unified JSON format.`,
+		RunE: run,
+	}
+
+	// Session selection flags are shared with subcommands
+	rootCmd.PersistentFlags().StringVar(&agentType, "agent", "", "Filter by agent type ("+strings.Join(registry.Names(), ", ")+")")
+	rootCmd.PersistentFlags().StringVar(&sessionID, "session-id", "", "Filter by specific session ID")
+	rootCmd.PersistentFlags().StringVar(&since, "since", "", "Filter sessions since timestamp (RFC3339)")
+	rootCmd.PersistentFlags().StringVar(&until, "until", "", "Filter sessions until timestamp (RFC3339)")
+	rootCmd.PersistentFlags().IntVarP(&jobs, "jobs", "j", 0, "Number of session files to parse in parallel (default: number of CPUs)")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Parse everything from scratch without reading or updating the cache")
+	rootCmd.PersistentFlags().BoolVar(&includeThinking, "include-thinking", true, "Include model thinking and redacted_thinking blocks")
+	rootCmd.PersistentFlags().BoolVar(&stripThinking, "strip-thinking", false, "Remove model thinking blocks (same as --include-thinking=false)")
+	rootCmd.MarkFlagsMutuallyExclusive("include-thinking", "strip-thinking")
+	rootCmd.PersistentFlags().StringVar(&branch, "branch", filter.BranchAll, "Which branches of rewound conversations to include (active, all)")
+	rootCmd.PersistentFlags().StringVar(&visibleTo, "visible-to", "", "Only include messages visible to the user or to the agent (user, agent)")
+	rootCmd.PersistentFlags().BoolVar(&printDiagnostics, "diagnostics", false, "Print every record that was skipped or only partly read to stderr")
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "Fail if any record was skipped or only partly read")
+
+	// Each source adds the flags that choose where and how it reads
+	registry.AddFlags(rootCmd.PersistentFlags())
+
+	// Output flags
+	rootCmd.Flags().StringVarP(&outFile, "output", "o", "", "Output file (default: stdout)")
+	rootCmd.Flags().StringVar(&format, "format", formatJSON, "Output format (json, ndjson, summary, markdown, html)")
+	rootCmd.Flags().BoolVar(&pretty, "pretty", false, "Pretty-print JSON output")
+	rootCmd.Flags().BoolVar(&summary, "summary", false, "Output human-readable summary instead of JSON (same as --format summary)")
+	rootCmd.Flags().StringVar(&extractMedia, "extract-media", "", "Write image and document attachments to this directory and reference them by path")
+
+	rootCmd.AddCommand(newCacheCmd())
+	rootCmd.AddCommand(newSearchCmd())
+	rootCmd.AddCommand(newStatsCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	// Resolve output format
+	if summary {
+		if cmd.Flags().Changed("format") && format != formatSummary {
+			return fmt.Errorf("--summary cannot be combined with --format %s", format)
+		}
+		format = formatSummary
+	}
+
+	switch format {
+	case formatJSON, formatNDJSON, formatSummary, formatMarkdown, formatHTML:
+	default:
+		return fmt.Errorf("invalid --format %q (expected json, ndjson, summary, markdown or html)", format)
+	}
+
+	sel, err := newSelection()
+	if err != nil {
+		return err
+	}
+
+	var extractor *media.Extractor
+	if extractMedia != "" {
+		extractor = media.NewExtractor(extractMedia)
+	}
+
+	// Open output
+	var writer *os.File
+	if outFile != "" {
+		writer, err = os.Create(outFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer writer.Close()
+	} else {
+		writer = os.Stdout
+	}
+
+	// NDJSON is written session by session as each one is parsed
+	if format == formatNDJSON {
+		ndjsonWriter := output.NewNDJSONWriter(writer)
+		return sel.forEach(func(session model.Session) error {
+			session, err := extractor.Session(session)
+			if err != nil {
+				return err
+			}
+			if err := ndjsonWriter.WriteSession(session); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		})
+	}
+
+	// Other formats need every session up front
+	var filteredSessions []model.Session
+	err = sel.forEach(func(session model.Session) error {
+		session, err := extractor.Session(session)
+		if err != nil {
+			return err
+		}
+		filteredSessions = append(filteredSessions, session)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Choose output format
+	switch format {
+	case formatSummary:
+		summaryWriter := output.NewSummaryWriter(writer)
+		if err := summaryWriter.Write(filteredSessions); err != nil {
+			return fmt.Errorf("failed to write summary: %w", err)
+		}
+	case formatMarkdown:
+		markdownWriter := output.NewMarkdownWriter(writer)
+		if err := markdownWriter.Write(filteredSessions); err != nil {
+			return fmt.Errorf("failed to write markdown: %w", err)
+		}
+	case formatHTML:
+		htmlWriter := output.NewHTMLWriter(writer)
+		if err := htmlWriter.Write(filteredSessions); err != nil {
+			return fmt.Errorf("failed to write html: %w", err)
+		}
+	default:
+		outputWriter := output.NewWriter(writer, pretty)
+		if err := outputWriter.Write(filteredSessions, sel.diagnostics.Diagnostics()); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	return nil
+}
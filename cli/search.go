@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/block/braindump/internal/search"
+	"github.com/block/braindump/model"
+	"github.com/spf13/cobra"
+)
+
+// ANSI escape sequences used to highlight matches
+const (
+	ansiHighlight = "\x1b[1;31m"
+	ansiDim       = "\x1b[2m"
+	ansiReset     = "\x1b[0m"
+)
+
+var (
+	searchRegex      bool
+	searchIgnoreCase bool
+	searchIn         []string
+	searchContext    int
+	searchColor      string
+)
+
+// newSearchCmd creates the "search" command
+func newSearchCmd() *cobra.Command {
+	searchCmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search text, thinking, tool inputs and tool results across sessions",
+		Long: `Search scans message text, thinking, tool inputs and tool results in every selected
+session (including subagents) and prints each hit with its session ID,
+timestamp, role and a highlighted snippet.
+
+The session selection flags (--agent, --session-id, --since, --until, ...)
+apply to the search.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSearch,
+	}
+
+	searchCmd.Flags().BoolVarP(&searchRegex, "regex", "E", false, "Treat the query as a regular expression")
+	searchCmd.Flags().BoolVarP(&searchIgnoreCase, "ignore-case", "i", false, "Match case-insensitively")
+	searchCmd.Flags().StringSliceVar(&searchIn, "in", nil, "Limit the search to these scopes ("+strings.Join(search.Scopes, ", ")+"; default: all)")
+	searchCmd.Flags().IntVarP(&searchContext, "context", "C", search.DefaultContext, "Characters of context to show around each match")
+	searchCmd.Flags().StringVar(&searchColor, "color", "auto", "Highlight matches (auto, always, never)")
+
+	return searchCmd
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	pattern, err := search.Compile(args[0], searchRegex, searchIgnoreCase)
+	if err != nil {
+		return err
+	}
+
+	if err := search.ValidateScopes(searchIn); err != nil {
+		return fmt.Errorf("invalid --in: %w", err)
+	}
+
+	var color bool
+	switch searchColor {
+	case "always":
+		color = true
+	case "never":
+		color = false
+	case "auto":
+		color = isTerminal(os.Stdout)
+	default:
+		return fmt.Errorf("invalid --color %q (expected auto, always or never)", searchColor)
+	}
+
+	sel, err := newSelection()
+	if err != nil {
+		return err
+	}
+
+	opts := search.Options{
+		Pattern: pattern,
+		In:      searchIn,
+		Context: searchContext,
+	}
+
+	out := cmd.OutOrStdout()
+	count := 0
+
+	err = sel.forEach(func(session model.Session) error {
+		for _, hit := range search.Session(session, opts) {
+			count++
+			if err := writeHit(out, hit, color); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		fmt.Fprintln(os.Stderr, "No matches found.")
+	}
+
+	return nil
+}
+
+// writeHit prints a search hit: a header line followed by the snippet
+func writeHit(w io.Writer, hit search.Hit, color bool) error {
+	header := fmt.Sprintf("%s %s %s", hit.AgentType, hit.SessionID, hit.Timestamp.Format("2006-01-02 15:04:05"))
+	if hit.AgentID != "" {
+		header += " subagent:" + hit.AgentID
+	}
+	header += " " + hit.Role + " [" + hit.Scope
+	if hit.ToolName != "" {
+		header += ":" + hit.ToolName
+	}
+	header += "]"
+
+	if color {
+		header = ansiDim + header + ansiReset
+	}
+
+	_, err := fmt.Fprintf(w, "%s\n    %s\n", header, highlight(hit, color))
+	return err
+}
+
+// highlight marks the matches in a hit's snippet, with ANSI color or with ** when color is off
+func highlight(hit search.Hit, color bool) string {
+	open, closing := "**", "**"
+	if color {
+		open, closing = ansiHighlight, ansiReset
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range hit.Matches {
+		b.WriteString(hit.Snippet[last:m[0]])
+		b.WriteString(open)
+		b.WriteString(hit.Snippet[m[0]:m[1]])
+		b.WriteString(closing)
+		last = m[1]
+	}
+	b.WriteString(hit.Snippet[last:])
+
+	return b.String()
+}
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/block/braindump/cache"
+	"github.com/block/braindump/diagnostics"
+	"github.com/block/braindump/internal/claude"
+	"github.com/block/braindump/internal/filter"
+	"github.com/block/braindump/internal/goose"
+	"github.com/block/braindump/model"
+	"github.com/block/braindump/source"
+)
+
+// NewRegistry returns a registry holding the built-in agent sources, to
+// which programs embedding the command add their own before calling Main
+func NewRegistry() *source.Registry {
+	registry := source.NewRegistry()
+	claude.Register(registry)
+	goose.Register(registry)
+	return registry
+}
+
+// selection is the set of sources and filters chosen on the command line
+type selection struct {
+	registry *source.Registry
+	names    []string
+	filter   filter.Options
+	// cache stores parsed sessions between runs, or is nil
+	cache *cache.Cache
+	// diagnostics collects the problems the sources found while reading
+	diagnostics *diagnostics.Collector
+}
+
+// newSelection resolves the session selection flags
+func newSelection() (*selection, error) {
+	// Parse time filters
+	var sinceTime, untilTime time.Time
+	var err error
+
+	if since != "" {
+		sinceTime, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --since timestamp: %w", err)
+		}
+	}
+
+	if until != "" {
+		untilTime, err = time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --until timestamp: %w", err)
+		}
+	}
+
+	switch visibleTo {
+	case "", filter.VisibleToUser, filter.VisibleToAgent:
+	default:
+		return nil, fmt.Errorf("invalid --visible-to %q (expected %s or %s)", visibleTo, filter.VisibleToUser, filter.VisibleToAgent)
+	}
+
+	switch branch {
+	case filter.BranchActive, filter.BranchAll:
+	default:
+		return nil, fmt.Errorf("invalid --branch %q (expected %s or %s)", branch, filter.BranchActive, filter.BranchAll)
+	}
+
+	// Resolve sources (all registered sources, or just the requested one)
+	names := sources.Names()
+	if agentType != "" {
+		if !sources.Has(agentType) {
+			return nil, fmt.Errorf("unknown --agent %q (available: %s)", agentType, strings.Join(names, ", "))
+		}
+		names = []string{agentType}
+	}
+
+	// Without a cache directory (e.g. no $HOME under cron), sessions are
+	// read without caching rather than not at all
+	var store *cache.Cache
+	if !noCache {
+		dir, err := cache.DefaultDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not caching parsed sessions: %v\n", err)
+		} else {
+			store = cache.New(dir)
+		}
+	}
+
+	return &selection{
+		registry:    sources,
+		names:       names,
+		cache:       store,
+		diagnostics: diagnostics.NewCollector(),
+		filter: filter.Options{
+			AgentType: agentType,
+			SessionID: sessionID,
+			Since:     sinceTime,
+			Until:     untilTime,
+
+			StripThinking: stripThinking || !includeThinking,
+			VisibleTo:     visibleTo,
+			Branch:        branch,
+		},
+	}, nil
+}
+
+// forEach streams sessions from the selected sources, calling fn for each
+// session that passes the filters, with excluded content removed. Sources
+// whose data is not present on this machine are skipped. Problems found while
+// reading are reported once every source has been read, or as soon as one is
+// found with --strict.
+func (s *selection) forEach(fn func(model.Session) error) error {
+	for _, name := range s.names {
+		cfg := source.Config{Jobs: jobs, Cache: s.cache, Diagnostics: s.diagnostics}
+
+		src, err := s.registry.Open(name, cfg)
+		if err != nil {
+			return err
+		}
+
+		if !src.Detect() {
+			continue
+		}
+
+		for session, err := range src.Sessions() {
+			var hinted source.HintError
+			if errors.As(err, &hinted) {
+				return fmt.Errorf("failed to read %s sessions: %w (%s)", name, err, hinted.Hint())
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read %s sessions: %w", name, err)
+			}
+
+			if strict && s.diagnostics.Incomplete() > 0 {
+				return s.report()
+			}
+
+			if !filter.Match(session, s.filter) {
+				continue
+			}
+
+			if err := fn(filter.Content(session, s.filter)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.report()
+}
+
+// report prints the problems found while reading: each of them with
+// --diagnostics, or else how many there were. With --strict any record that
+// was skipped or only partly read is an error. Advisory diagnostics, such as
+// a newer Goose schema, were already printed as warnings by their source.
+func (s *selection) report() error {
+	incomplete := s.diagnostics.Incomplete()
+	if incomplete == 0 {
+		return nil
+	}
+
+	if printDiagnostics {
+		for _, d := range s.diagnostics.Diagnostics() {
+			if !d.Advisory() {
+				fmt.Fprintln(os.Stderr, d)
+			}
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: %d record(s) could not be fully read; run with --diagnostics for details\n", incomplete)
+	}
+
+	if strict {
+		return fmt.Errorf("%d record(s) could not be fully read (--strict)", incomplete)
+	}
+	return nil
+}
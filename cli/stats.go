@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/block/braindump/internal/stats"
+	"github.com/block/braindump/model"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsBy     []string
+	statsPrices string
+	statsFormat string
+)
+
+// newStatsCmd creates the "stats" command and its subcommands
+func newStatsCmd() *cobra.Command {
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Report statistics across sessions",
+	}
+
+	tokensCmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Roll up token usage and estimated cost",
+		Long: `Roll up token usage per agent, model, working directory, day and session,
+with an estimated cost from a price table.
+
+The price table is a JSON file giving prices per million tokens, loaded from
+--prices or, if present, ~/.config/braindump/prices.json:
+
+  {
+    "currency": "USD",
+    "models": {
+      "claude-sonnet-4-5": {"input": 3.00, "output": 15.00, "cache_write": 3.75, "cache_read": 0.30}
+    }
+  }
+
+Model names match exactly or by prefix, so "claude-sonnet-4-5" also prices
+"claude-sonnet-4-5-20250929". Prompt-cache prices default to 1.25x (write)
+and 0.1x (read) the input price.`,
+		Args: cobra.NoArgs,
+		RunE: runStatsTokens,
+	}
+
+	tokensCmd.Flags().StringSliceVar(&statsBy, "by", []string{stats.ByAgent, stats.ByModel, stats.ByDir, stats.ByDay}, "Dimensions to report ("+strings.Join(stats.Dimensions, ", ")+")")
+	tokensCmd.Flags().StringVar(&statsPrices, "prices", "", "Price table JSON file (default: ~/.config/braindump/prices.json if present)")
+	tokensCmd.Flags().StringVar(&statsFormat, "format", "table", "Output format (table, json)")
+
+	statsCmd.AddCommand(tokensCmd)
+	return statsCmd
+}
+
+func runStatsTokens(cmd *cobra.Command, args []string) error {
+	if err := stats.ValidateDimensions(statsBy); err != nil {
+		return fmt.Errorf("invalid --by: %w", err)
+	}
+
+	if statsFormat != "table" && statsFormat != "json" {
+		return fmt.Errorf("invalid --format %q (expected table or json)", statsFormat)
+	}
+
+	prices, err := loadPrices()
+	if err != nil {
+		return err
+	}
+
+	sel, err := newSelection()
+	if err != nil {
+		return err
+	}
+
+	aggregator := stats.NewAggregator(prices)
+	err = sel.forEach(func(session model.Session) error {
+		aggregator.Add(session)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if statsFormat == "json" {
+		return writeStatsJSON(cmd.OutOrStdout(), aggregator, prices)
+	}
+	return writeStatsTable(cmd.OutOrStdout(), aggregator, prices)
+}
+
+// loadPrices loads the price table from --prices, or the default location if it exists
+func loadPrices() (*stats.PriceTable, error) {
+	if statsPrices != "" {
+		return stats.LoadPrices(statsPrices)
+	}
+
+	path, err := stats.DefaultPricesPath()
+	if err != nil {
+		return nil, nil //nolint:nilerr // no config directory means no default price table
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil //nolint:nilerr // a missing default price table is not an error
+	}
+	return stats.LoadPrices(path)
+}
+
+// writeStatsTable writes one table per dimension
+func writeStatsTable(w io.Writer, aggregator *stats.Aggregator, prices *stats.PriceTable) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	writeRow := func(key string, usage stats.Usage) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t", key, usage.Sessions, usage.Messages,
+			usage.InputTokens, usage.CacheCreationTokens, usage.CacheReadTokens, usage.OutputTokens, usage.TotalTokens)
+		if prices != nil {
+			fmt.Fprintf(tw, "%.2f\t%d\t", usage.Cost, usage.UnpricedTokens)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	for i, dim := range statsBy {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+
+		fmt.Fprintf(tw, "%s\tSESSIONS\tMESSAGES\tINPUT\tCACHE WRITE\tCACHE READ\tOUTPUT\tTOTAL\t", strings.ToUpper(dim))
+		if prices != nil {
+			fmt.Fprintf(tw, "COST (%s)\tUNPRICED\t", prices.Currency)
+		}
+		fmt.Fprintln(tw)
+
+		for _, row := range aggregator.Report(dim) {
+			writeRow(row.Key, row.Usage)
+		}
+	}
+
+	fmt.Fprintln(tw)
+	writeRow("TOTAL", aggregator.Totals())
+
+	return tw.Flush()
+}
+
+// writeStatsJSON writes the totals and each requested dimension as JSON
+func writeStatsJSON(w io.Writer, aggregator *stats.Aggregator, prices *stats.PriceTable) error {
+	report := struct {
+		Currency string                 `json:"currency,omitempty"`
+		Totals   stats.Usage            `json:"totals"`
+		By       map[string][]stats.Row `json:"by"`
+	}{
+		Totals: aggregator.Totals(),
+		By:     make(map[string][]stats.Row),
+	}
+	if prices != nil {
+		report.Currency = prices.Currency
+	}
+
+	for _, dim := range statsBy {
+		report.By[dim] = aggregator.Report(dim)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
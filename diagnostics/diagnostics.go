@@ -0,0 +1,69 @@
+package diagnostics
+
+import (
+	"sync"
+
+	"github.com/block/braindump/model"
+)
+
+// Collector gathers the problems sources find while reading sessions.
+// It is safe for concurrent use. A nil Collector discards everything added to it.
+type Collector struct {
+	mu          sync.Mutex
+	diagnostics []model.Diagnostic
+}
+
+// NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Add records a diagnostic
+func (c *Collector) Add(d model.Diagnostic) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+// Len returns how many diagnostics have been recorded
+func (c *Collector) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.diagnostics)
+}
+
+// Incomplete returns how many of the diagnostics record data that was
+// skipped or read in part, leaving out advisory ones
+func (c *Collector) Incomplete() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, d := range c.diagnostics {
+		if !d.Advisory() {
+			n++
+		}
+	}
+	return n
+}
+
+// Diagnostics returns a copy of the diagnostics recorded so far, in the order they were added
+func (c *Collector) Diagnostics() []model.Diagnostic {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.diagnostics) == 0 {
+		return nil
+	}
+	return append([]model.Diagnostic(nil), c.diagnostics...)
+}
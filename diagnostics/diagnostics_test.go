@@ -0,0 +1,75 @@
+package diagnostics
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/block/braindump/model"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector()
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collector.Add(model.Diagnostic{Agent: "claude", File: "s1.jsonl", Line: i + 1, Kind: model.DiagnosticMalformedRecord})
+		}()
+	}
+	wg.Wait()
+
+	if got := collector.Len(); got != 10 {
+		t.Errorf("Len: got %d, want 10", got)
+	}
+
+	// A newer schema is reported but does not count as an incomplete record
+	collector.Add(model.Diagnostic{Agent: "goose", File: "sessions.db", Kind: model.DiagnosticNewerSchema})
+	if got := collector.Incomplete(); got != 10 {
+		t.Errorf("Incomplete: got %d, want 10", got)
+	}
+
+	// The returned slice is a copy
+	diagnostics := collector.Diagnostics()
+	diagnostics[0].Line = 0
+	if collector.Diagnostics()[0].Line == 0 {
+		t.Error("Diagnostics returned the collector's own slice")
+	}
+}
+
+func TestNilCollector(t *testing.T) {
+	var collector *Collector
+	collector.Add(model.Diagnostic{Kind: model.DiagnosticReadFailed})
+
+	if collector.Len() != 0 || collector.Incomplete() != 0 || collector.Diagnostics() != nil {
+		t.Error("a nil collector should discard diagnostics")
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	tests := []struct {
+		name       string
+		diagnostic model.Diagnostic
+		want       string
+	}{
+		{
+			name:       "line",
+			diagnostic: model.Diagnostic{Agent: "claude", File: "/p/s1.jsonl", Line: 12, Kind: model.DiagnosticMalformedRecord, Message: "unexpected end of JSON input"},
+			want:       "claude: /p/s1.jsonl:12: malformed_record: unexpected end of JSON input",
+		},
+		{
+			name:       "row",
+			diagnostic: model.Diagnostic{Agent: "goose", File: "sessions.db", SessionID: "s1", Row: "m1", Field: "created_timestamp", Kind: model.DiagnosticInvalidTimestamp, Message: "bad"},
+			want:       "goose: sessions.db (session s1, row m1, field created_timestamp): invalid_timestamp: bad",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.diagnostic.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
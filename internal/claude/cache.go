@@ -0,0 +1,117 @@
+package claude
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheNamespace holds cached Claude parse results. Bump the version
+// whenever parsing changes what is recorded in fileState.
+const cacheNamespace = "claude-v14"
+
+// eventsNamespaceSuffix separates states parsed with events from those
+// parsed without, so enabling events never serves a state that lacks them
+const eventsNamespaceSuffix = "-events"
+
+// tailSize is how many bytes before the cached offset are hashed to check
+// that a grown file was appended to rather than rewritten
+const tailSize = 4096
+
+// cacheEntry records how far a file has been parsed and the result so far
+type cacheEntry struct {
+	ModTime  time.Time `json:"mod_time"`
+	Size     int64     `json:"size"`
+	Offset   int64     `json:"offset"`
+	TailHash string    `json:"tail_hash"`
+	State    fileState `json:"state"`
+}
+
+// parseFile parses a Claude JSONL file, consulting the cache when enabled.
+// Unchanged files are served from the cache; files that have only grown are
+// parsed from where the previous run stopped.
+func (r *Reader) parseFile(path string) (*fileState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	key := path
+	if abs, err := filepath.Abs(path); err == nil {
+		key = abs
+	}
+
+	namespace := cacheNamespace
+	if r.events {
+		namespace += eventsNamespaceSuffix
+	}
+
+	var entry cacheEntry
+	cached := r.cache != nil && r.cache.Load(namespace, key, &entry)
+
+	if cached && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		r.reportFile(path, &entry.State)
+		return &entry.State, nil
+	}
+
+	state := &fileState{}
+	var offset int64
+	if cached && info.Size() > entry.Size && tailMatches(file, entry.Offset, entry.TailHash) {
+		state = &entry.State
+		offset = entry.Offset
+	}
+
+	offset, complete, err := state.readFrom(file, offset, r.events)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only cache files that end on a line boundary; a partially written
+	// last line would otherwise be parsed twice on the next run
+	if r.cache != nil && complete {
+		tail, err := hashTail(file, offset)
+		if err == nil {
+			err = r.cache.Store(namespace, key, cacheEntry{
+				ModTime:  info.ModTime(),
+				Size:     info.Size(),
+				Offset:   offset,
+				TailHash: tail,
+				State:    *state,
+			})
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to cache %s: %v\n", path, err)
+		}
+	}
+
+	r.reportFile(path, state)
+	return state, nil
+}
+
+// hashTail hashes the bytes immediately before offset
+func hashTail(file *os.File, offset int64) (string, error) {
+	start := max(offset-tailSize, 0)
+	buf := make([]byte, offset-start)
+	if _, err := file.ReadAt(buf, start); err != nil && err != io.EOF {
+		return "", err
+	}
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// tailMatches reports whether the bytes before offset are unchanged since they were cached
+func tailMatches(file *os.File, offset int64, want string) bool {
+	got, err := hashTail(file, offset)
+	return err == nil && got == want
+}
@@ -0,0 +1,169 @@
+package claude
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/block/braindump/cache"
+	"github.com/block/braindump/diagnostics"
+	"github.com/block/braindump/model"
+)
+
+const (
+	cacheLine1 = `{"type":"user","sessionId":"s1","uuid":"u1","timestamp":"2026-02-07T12:00:00Z","message":{"role":"user","content":"first"}}` + "\n"
+	cacheLine2 = `{"type":"assistant","sessionId":"s1","uuid":"a1","parentUuid":"u1","timestamp":"2026-02-07T12:00:01Z","message":{"role":"assistant","content":"second"}}` + "\n"
+	cacheLine3 = `{"type":"user","sessionId":"s1","uuid":"u2","parentUuid":"a1","timestamp":"2026-02-07T12:00:02Z","message":{"role":"user","content":"third"}}` + "\n"
+)
+
+func TestParseFileIncremental(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.jsonl")
+	if err := os.WriteFile(path, []byte(cacheLine1+cacheLine2), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(Options{Roots: []string{dir}, Cache: cache.New(t.TempDir())})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := reader.parseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(state.Messages))
+	}
+
+	// Append a line: only the new line is parsed, on top of the cached state
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(cacheLine3); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	state, err = reader.parseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Messages) != 3 {
+		t.Fatalf("After append: expected 3 messages, got %d", len(state.Messages))
+	}
+	if got := state.Messages[2].UUID; got != "u2" {
+		t.Errorf("After append: last UUID got %q, want %q", got, "u2")
+	}
+
+	// Unchanged file is served from the cache
+	state, err = reader.parseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Messages) != 3 {
+		t.Errorf("Cached: expected 3 messages, got %d", len(state.Messages))
+	}
+}
+
+func TestParseFileRewritten(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.jsonl")
+	if err := os.WriteFile(path, []byte(cacheLine1), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(Options{Roots: []string{dir}, Cache: cache.New(t.TempDir())})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reader.parseFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewrite the file with different, longer content: it must be parsed from scratch
+	if err := os.WriteFile(path, []byte(cacheLine3+cacheLine2), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := reader.parseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(state.Messages))
+	}
+	if got := state.Messages[0].UUID; got != "u2" {
+		t.Errorf("First UUID: got %q, want %q", got, "u2")
+	}
+}
+
+func TestParseFileLongAndMalformedLines(t *testing.T) {
+	// A tool result far larger than any fixed line buffer
+	long := strings.Repeat("x", 3<<20)
+	longLine := `{"type":"user","sessionId":"s1","uuid":"r1","parentUuid":"a1","timestamp":"2026-02-07T12:00:01Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"` + long + `"}]}}` + "\n"
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.jsonl")
+	if err := os.WriteFile(path, []byte(cacheLine1+"\n"+longLine+"{not json\n"+cacheLine2), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	collector := diagnostics.NewCollector()
+	reader, err := NewReader(Options{Roots: []string{dir}, Cache: cache.New(t.TempDir()), Diagnostics: collector})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := reader.parseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(state.Messages))
+	}
+	if got := state.Messages[1].Content[0].ToolContent; got != long {
+		t.Errorf("Long tool result truncated to %d bytes", len(got))
+	}
+	got := collector.Diagnostics()
+	if len(got) != 1 || got[0].Agent != "claude" || got[0].File != path || got[0].Line != 4 || got[0].Kind != model.DiagnosticMalformedRecord {
+		t.Errorf("Expected line 4 to be reported as malformed, got %+v", got)
+	}
+
+	// Line numbers of appended lines continue from the cached state. The
+	// unterminated last line is still being written, so it is not malformed.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badTimestamp := `{"type":"user","sessionId":"s1","uuid":"u3","parentUuid":"u2","timestamp":"yesterday","message":{"role":"user","content":"fourth"}}` + "\n"
+	if _, err := file.WriteString(cacheLine3 + "]\n" + badTimestamp + `{"type":"user","sessionId"`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	state, err = reader.parseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Messages) != 5 {
+		t.Fatalf("After append: expected 5 messages, got %d", len(state.Messages))
+	}
+	var lines []string
+	for _, d := range state.Diagnostics {
+		lines = append(lines, fmt.Sprintf("%d %s %s", d.Line, d.Field, d.Kind))
+	}
+	want := []string{"4  malformed_record", "7  malformed_record", "8 timestamp invalid_timestamp"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("After append: diagnostics got %q, want %q", lines, want)
+	}
+	// An invalid timestamp does not reset the session's times
+	if state.CreatedAt.IsZero() || state.UpdatedAt.IsZero() {
+		t.Errorf("After append: times reset to %v - %v", state.CreatedAt, state.UpdatedAt)
+	}
+}
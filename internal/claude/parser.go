@@ -4,7 +4,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/block/braindump/internal/model"
+	"github.com/block/braindump/internal/timestamp"
+	"github.com/block/braindump/model"
 )
 
 // parseMessage parses a Claude message from raw JSON
@@ -12,9 +13,9 @@ func parseMessage(raw map[string]any) *model.Message {
 	uuid, _ := raw["uuid"].(string)
 	parentUUID, _ := raw["parentUuid"].(string)
 
-	var timestamp time.Time
+	var ts time.Time
 	if tsStr, ok := raw["timestamp"].(string); ok {
-		timestamp, _ = time.Parse(time.RFC3339, tsStr)
+		ts, _ = timestamp.Parse(tsStr)
 	}
 
 	// Extract message content
@@ -65,6 +66,19 @@ func parseMessage(raw map[string]any) *model.Message {
 		metadata.RequestID = requestID
 	}
 
+	// Meta messages are context Claude Code adds to the conversation without showing it
+	if isMeta, hasMeta := raw["isMeta"].(bool); hasMeta && isMeta {
+		visible := false
+		metadata.UserVisible = &visible
+	}
+
+	// The summary that replaces compacted context is sent as a user message.
+	// Claude Code marks it isVisibleInTranscriptOnly, which still shows it to
+	// the user in the transcript, so only isMeta hides it.
+	if isSummary, hasSummary := raw["isCompactSummary"].(bool); hasSummary && isSummary {
+		metadata.IsCompactSummary = true
+	}
+
 	// Extract model from message
 	if modelStr, hasModel := messageData["model"].(string); hasModel {
 		metadata.Model = modelStr
@@ -78,13 +92,110 @@ func parseMessage(raw map[string]any) *model.Message {
 	return &model.Message{
 		UUID:       uuid,
 		ParentUUID: parentUUID,
-		Timestamp:  timestamp,
+		Timestamp:  ts,
 		Role:       role,
 		Content:    contentBlocks,
 		Metadata:   metadata,
 	}
 }
 
+// parseCompactBoundary parses the system record Claude writes where it
+// compacted the conversation, as a system message carrying a
+// "compact_boundary" block
+func parseCompactBoundary(raw map[string]any) model.Message {
+	uuid, _ := raw["uuid"].(string)
+	content, _ := raw["content"].(string)
+
+	// The boundary starts a new chain; logicalParentUuid links it to the
+	// last message before compaction
+	parentUUID, _ := raw["parentUuid"].(string)
+	if parentUUID == "" {
+		parentUUID, _ = raw["logicalParentUuid"].(string)
+	}
+
+	var ts time.Time
+	if tsStr, ok := raw["timestamp"].(string); ok {
+		ts, _ = timestamp.Parse(tsStr)
+	}
+
+	compaction := &model.Compaction{}
+	if metadata, ok := raw["compactMetadata"].(map[string]any); ok {
+		compaction.Trigger, _ = metadata["trigger"].(string)
+		if preTokens, ok := metadata["preTokens"].(float64); ok {
+			compaction.PreTokens = int(preTokens)
+		}
+	}
+
+	return model.Message{
+		UUID:       uuid,
+		ParentUUID: parentUUID,
+		Timestamp:  ts,
+		Role:       "system",
+		Content: []model.ContentBlock{
+			{Type: "compact_boundary", Text: content, Compaction: compaction},
+		},
+	}
+}
+
+// eventTypes maps the record types read as events to their kinds
+var eventTypes = map[string]string{
+	"system":                model.EventSystem,
+	"progress":              model.EventProgress,
+	"file-history-snapshot": model.EventFileHistorySnapshot,
+}
+
+// envelopeFields are carried by every record and describe where it was
+// written rather than what happened, so they are left out of event data
+var envelopeFields = map[string]bool{
+	"type": true, "subtype": true, "uuid": true, "parentUuid": true, "logicalParentUuid": true,
+	"timestamp": true, "sessionId": true, "cwd": true, "gitBranch": true, "version": true,
+	"userType": true, "isSidechain": true, "isMeta": true, "slug": true, "agentId": true,
+	"level": true, "content": true, "toolUseID": true,
+}
+
+// parseEvent parses a system, progress or file history snapshot record,
+// or returns nil for other records
+func parseEvent(raw map[string]any) *model.Event {
+	recordType, _ := raw["type"].(string)
+	kind, ok := eventTypes[recordType]
+	if !ok {
+		return nil
+	}
+
+	event := &model.Event{Kind: kind}
+	event.Subtype, _ = raw["subtype"].(string)
+	event.Level, _ = raw["level"].(string)
+	event.UUID, _ = raw["uuid"].(string)
+	event.ToolUseID, _ = raw["toolUseID"].(string)
+	event.Text, _ = raw["content"].(string)
+
+	tsStr, _ := raw["timestamp"].(string)
+	switch kind {
+	case model.EventProgress:
+		// Progress records nest what is progressing under data
+		if data, ok := raw["data"].(map[string]any); ok {
+			event.Subtype, _ = data["type"].(string)
+		}
+	case model.EventFileHistorySnapshot:
+		if snapshot, ok := raw["snapshot"].(map[string]any); ok && tsStr == "" {
+			tsStr, _ = snapshot["timestamp"].(string)
+		}
+	}
+	event.Timestamp, _ = timestamp.Parse(tsStr)
+
+	for key, value := range raw {
+		if envelopeFields[key] {
+			continue
+		}
+		if event.Data == nil {
+			event.Data = make(map[string]any)
+		}
+		event.Data[key] = value
+	}
+
+	return event
+}
+
 // parseContentBlock parses a content block
 func parseContentBlock(block map[string]any) *model.ContentBlock {
 	blockType, _ := block["type"].(string)
@@ -97,6 +208,25 @@ func parseContentBlock(block map[string]any) *model.ContentBlock {
 			Text: text,
 		}
 
+	case "thinking":
+		thinking, _ := block["thinking"].(string)
+		signature, _ := block["signature"].(string)
+		return &model.ContentBlock{
+			Type:      "thinking",
+			Thinking:  thinking,
+			Signature: signature,
+		}
+
+	case "redacted_thinking":
+		data, _ := block["data"].(string)
+		return &model.ContentBlock{
+			Type: "redacted_thinking",
+			Data: data,
+		}
+
+	case "image", "document":
+		return parseMediaBlock(blockType, block)
+
 	case "tool_use":
 		toolName, _ := block["name"].(string)
 		toolUseID, _ := block["id"].(string)
@@ -112,17 +242,65 @@ func parseContentBlock(block map[string]any) *model.ContentBlock {
 	case "tool_result":
 		toolUseID, _ := block["tool_use_id"].(string)
 		toolContent := extractToolContent(block["content"])
+		isError, _ := block["is_error"].(bool)
 
 		return &model.ContentBlock{
 			Type:        "tool_result",
 			ToolUseID:   toolUseID,
 			ToolContent: toolContent,
+			Parts:       parseToolResultParts(block["content"]),
+			IsError:     isError,
 		}
 	}
 
 	return nil
 }
 
+// parseMediaBlock parses an image or document block and its source
+func parseMediaBlock(blockType string, block map[string]any) *model.ContentBlock {
+	title, _ := block["title"].(string)
+	contentBlock := &model.ContentBlock{
+		Type:  blockType,
+		Title: title,
+	}
+
+	source, ok := block["source"].(map[string]any)
+	if !ok {
+		return contentBlock
+	}
+
+	contentBlock.SourceType, _ = source["type"].(string)
+	contentBlock.MediaType, _ = source["media_type"].(string)
+	contentBlock.URL, _ = source["url"].(string)
+	contentBlock.FileID, _ = source["file_id"].(string)
+	// Base64 and plain text sources carry their content inline
+	contentBlock.Data, _ = source["data"].(string)
+
+	return contentBlock
+}
+
+// parseToolResultParts parses the items of tool result content given as a list.
+// String content has no parts; it is kept in ToolContent only.
+func parseToolResultParts(content any) []model.ContentBlock {
+	contentArr, isArray := content.([]any)
+	if !isArray {
+		return nil
+	}
+
+	var parts []model.ContentBlock
+	for _, item := range contentArr {
+		if itemMap, isMap := item.(map[string]any); isMap {
+			if part := parseContentBlock(itemMap); part != nil {
+				parts = append(parts, *part)
+			}
+		} else if str, isStr := item.(string); isStr {
+			parts = append(parts, model.ContentBlock{Type: "text", Text: str})
+		}
+	}
+
+	return parts
+}
+
 // extractToolContent extracts content from tool result, handling both string and array formats
 func extractToolContent(content any) string {
 	// Content can be string or array
@@ -166,8 +344,34 @@ func parseTokenUsage(usage map[string]any) *model.TokenUsage {
 		tokens.OutputTokens = int(outputTokens)
 	}
 
-	// Calculate total
+	if cacheCreation, ok := usage["cache_creation_input_tokens"].(float64); ok {
+		tokens.CacheCreationInputTokens = int(cacheCreation)
+	}
+
+	if cacheRead, ok := usage["cache_read_input_tokens"].(float64); ok {
+		tokens.CacheReadInputTokens = int(cacheRead)
+	}
+
+	if tier, ok := usage["service_tier"].(string); ok {
+		tokens.ServiceTier = tier
+	}
+
+	if serverToolUse, ok := usage["server_tool_use"].(map[string]any); ok {
+		for name, value := range serverToolUse {
+			if count, ok := value.(float64); ok && count > 0 {
+				if tokens.ServerToolUse == nil {
+					tokens.ServerToolUse = make(map[string]int)
+				}
+				tokens.ServerToolUse[name] = int(count)
+			}
+		}
+	}
+
+	// Calculate totals (input_tokens excludes tokens written to or read from the cache)
 	tokens.TotalTokens = tokens.InputTokens + tokens.OutputTokens
+	if cached := tokens.CacheCreationInputTokens + tokens.CacheReadInputTokens; cached > 0 {
+		tokens.TotalWithCacheTokens = tokens.TotalTokens + cached
+	}
 
 	return tokens
 }
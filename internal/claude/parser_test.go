@@ -4,7 +4,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/block/braindump/internal/model"
+	"github.com/block/braindump/model"
 )
 
 func TestParseMessage(t *testing.T) {
@@ -136,6 +136,31 @@ func TestParseMessage(t *testing.T) {
 	}
 }
 
+func TestParseMessageMeta(t *testing.T) {
+	msg := parseMessage(map[string]any{
+		"uuid":    "meta-uuid",
+		"isMeta":  true,
+		"message": map[string]any{"role": "user", "content": "Caveat: the messages below were generated by local commands"},
+	})
+	if msg == nil || msg.Metadata.VisibleToUser() || !msg.Metadata.VisibleToAgent() {
+		t.Errorf("expected a meta message visible only to the agent, got %+v", msg)
+	}
+
+	msg = parseMessage(map[string]any{"message": map[string]any{"role": "user", "content": "hi"}})
+	if msg == nil || msg.Metadata.UserVisible != nil {
+		t.Errorf("expected no visibility flag on a regular message, got %+v", msg)
+	}
+
+	msg = parseMessage(map[string]any{
+		"isCompactSummary": true,
+		"isMeta":           true,
+		"message":          map[string]any{"role": "user", "content": "This session is being continued..."},
+	})
+	if msg == nil || !msg.Metadata.IsCompactSummary || msg.Metadata.VisibleToUser() {
+		t.Errorf("expected a hidden compact summary when isMeta is set, got %+v", msg)
+	}
+}
+
 func TestParseContentBlock(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -172,6 +197,64 @@ func TestParseContentBlock(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "thinking block",
+			block: map[string]any{
+				"type":      "thinking",
+				"thinking":  "Let me check the tests first",
+				"signature": "sig-abc",
+			},
+			expected: &model.ContentBlock{
+				Type:      "thinking",
+				Thinking:  "Let me check the tests first",
+				Signature: "sig-abc",
+			},
+		},
+		{
+			name: "image block",
+			block: map[string]any{
+				"type": "image",
+				"source": map[string]any{
+					"type":       "base64",
+					"media_type": "image/png",
+					"data":       "iVBORw0KGgo=",
+				},
+			},
+			expected: &model.ContentBlock{
+				Type:       "image",
+				SourceType: "base64",
+				MediaType:  "image/png",
+				Data:       "iVBORw0KGgo=",
+			},
+		},
+		{
+			name: "document block",
+			block: map[string]any{
+				"type":  "document",
+				"title": "spec.pdf",
+				"source": map[string]any{
+					"type": "url",
+					"url":  "https://example.com/spec.pdf",
+				},
+			},
+			expected: &model.ContentBlock{
+				Type:       "document",
+				SourceType: "url",
+				URL:        "https://example.com/spec.pdf",
+				Title:      "spec.pdf",
+			},
+		},
+		{
+			name: "redacted thinking block",
+			block: map[string]any{
+				"type": "redacted_thinking",
+				"data": "encrypted",
+			},
+			expected: &model.ContentBlock{
+				Type: "redacted_thinking",
+				Data: "encrypted",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -193,10 +276,74 @@ func TestParseContentBlock(t *testing.T) {
 			if result.ToolName != tt.expected.ToolName {
 				t.Errorf("ToolName: got %q, want %q", result.ToolName, tt.expected.ToolName)
 			}
+
+			if result.Thinking != tt.expected.Thinking {
+				t.Errorf("Thinking: got %q, want %q", result.Thinking, tt.expected.Thinking)
+			}
+
+			if result.Signature != tt.expected.Signature {
+				t.Errorf("Signature: got %q, want %q", result.Signature, tt.expected.Signature)
+			}
+
+			if result.Data != tt.expected.Data {
+				t.Errorf("Data: got %q, want %q", result.Data, tt.expected.Data)
+			}
+
+			if result.SourceType != tt.expected.SourceType || result.MediaType != tt.expected.MediaType {
+				t.Errorf("Source: got %q %q, want %q %q", result.SourceType, result.MediaType, tt.expected.SourceType, tt.expected.MediaType)
+			}
+
+			if result.URL != tt.expected.URL || result.Title != tt.expected.Title {
+				t.Errorf("URL/Title: got %q %q, want %q %q", result.URL, result.Title, tt.expected.URL, tt.expected.Title)
+			}
 		})
 	}
 }
 
+func TestParseToolResult(t *testing.T) {
+	block := map[string]any{
+		"type":        "tool_result",
+		"tool_use_id": "tool-1",
+		"is_error":    true,
+		"content": []any{
+			map[string]any{"type": "text", "text": "Screenshot failed"},
+			map[string]any{"type": "image", "source": map[string]any{"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgo="}},
+			"trailing note",
+		},
+	}
+
+	result := parseContentBlock(block)
+	if result == nil {
+		t.Fatal("parseContentBlock returned nil")
+	}
+
+	if !result.IsError {
+		t.Error("IsError: got false, want true")
+	}
+
+	if result.ToolContent != "Screenshot failed\n\ntrailing note" {
+		t.Errorf("ToolContent: got %q", result.ToolContent)
+	}
+
+	if len(result.Parts) != 3 {
+		t.Fatalf("Parts: got %d, want 3", len(result.Parts))
+	}
+	for i, want := range []string{"text", "image", "text"} {
+		if result.Parts[i].Type != want {
+			t.Errorf("Parts[%d].Type: got %q, want %q", i, result.Parts[i].Type, want)
+		}
+	}
+	if result.Parts[1].MediaType != "image/png" {
+		t.Errorf("image part MediaType: got %q", result.Parts[1].MediaType)
+	}
+
+	// String content has no parts
+	plain := parseContentBlock(map[string]any{"type": "tool_result", "tool_use_id": "tool-2", "content": "ok"})
+	if plain.ToolContent != "ok" || plain.Parts != nil || plain.IsError {
+		t.Errorf("plain result: got %+v", plain)
+	}
+}
+
 func TestParseTokenUsage(t *testing.T) {
 	usage := map[string]any{
 		"input_tokens":  float64(100),
@@ -218,6 +365,46 @@ func TestParseTokenUsage(t *testing.T) {
 	}
 }
 
+func TestParseTokenUsageCache(t *testing.T) {
+	usage := map[string]any{
+		"input_tokens":                float64(4),
+		"cache_creation_input_tokens": float64(2000),
+		"cache_read_input_tokens":     float64(30000),
+		"output_tokens":               float64(500),
+		"service_tier":                "standard",
+		"server_tool_use": map[string]any{
+			"web_search_requests": float64(2),
+			"web_fetch_requests":  float64(0),
+		},
+	}
+
+	result := parseTokenUsage(usage)
+
+	if result.CacheCreationInputTokens != 2000 {
+		t.Errorf("CacheCreationInputTokens: got %d, want 2000", result.CacheCreationInputTokens)
+	}
+
+	if result.CacheReadInputTokens != 30000 {
+		t.Errorf("CacheReadInputTokens: got %d, want 30000", result.CacheReadInputTokens)
+	}
+
+	if result.TotalTokens != 504 {
+		t.Errorf("TotalTokens: got %d, want 504", result.TotalTokens)
+	}
+
+	if result.TotalWithCacheTokens != 32504 {
+		t.Errorf("TotalWithCacheTokens: got %d, want 32504", result.TotalWithCacheTokens)
+	}
+
+	if result.ServiceTier != "standard" {
+		t.Errorf("ServiceTier: got %q, want %q", result.ServiceTier, "standard")
+	}
+
+	if len(result.ServerToolUse) != 1 || result.ServerToolUse["web_search_requests"] != 2 {
+		t.Errorf("ServerToolUse: got %v, want map[web_search_requests:2]", result.ServerToolUse)
+	}
+}
+
 func mustParseTime(s string) time.Time {
 	t, err := time.Parse(time.RFC3339, s)
 	if err != nil {
@@ -0,0 +1,89 @@
+package claude
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables consulted when locating Claude data
+const (
+	// EnvDir overrides the Claude data directories (a path list, like PATH)
+	EnvDir = "BRAINDUMP_CLAUDE_DIR"
+	// EnvConfigDir is Claude Code's own configuration directory override
+	EnvConfigDir = "CLAUDE_CONFIG_DIR"
+)
+
+// DefaultRoots returns the Claude data directories to read when none are given.
+// BRAINDUMP_CLAUDE_DIR takes precedence, then CLAUDE_CONFIG_DIR, then ~/.claude.
+func DefaultRoots() ([]string, error) {
+	if dirs := envRoots(); len(dirs) > 0 {
+		return dirs, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return []string{filepath.Join(homeDir, ".claude")}, nil
+}
+
+// envRoots returns the Claude data directories chosen in the environment, or nil
+func envRoots() []string {
+	if dirs := filepath.SplitList(os.Getenv(EnvDir)); len(dirs) > 0 {
+		return dirs
+	}
+	if configDir := os.Getenv(EnvConfigDir); configDir != "" {
+		return []string{configDir}
+	}
+	return nil
+}
+
+// noDataError explains why a root chosen by the user holds no Claude data
+func noDataError(root string) error {
+	if _, err := os.Stat(root); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s is neither a Claude config directory nor a projects directory", root)
+}
+
+// projectsDir resolves a root to the directory holding project session files.
+// A root may be a Claude config directory (containing "projects") or the
+// projects directory itself, e.g. an exported copy. It returns "" for roots
+// that are neither, so that other JSONL files in a config directory, such as
+// history.jsonl, are never read as sessions.
+func projectsDir(root string) string {
+	candidate := filepath.Join(root, "projects")
+	if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+		return candidate
+	}
+	if hasProjects(root) {
+		return root
+	}
+	return ""
+}
+
+// hasProjects reports whether dir holds project directories with session files
+func hasProjects(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if !file.IsDir() && strings.HasSuffix(file.Name(), ".jsonl") {
+				return true
+			}
+		}
+	}
+	return false
+}
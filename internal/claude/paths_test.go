@@ -0,0 +1,121 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRoots(t *testing.T) {
+	t.Setenv(EnvDir, "")
+	t.Setenv(EnvConfigDir, "/config/claude")
+
+	roots, err := DefaultRoots()
+	if err != nil {
+		t.Fatalf("DefaultRoots: %v", err)
+	}
+	if want := []string{"/config/claude"}; !reflect.DeepEqual(roots, want) {
+		t.Errorf("CLAUDE_CONFIG_DIR: got %v, want %v", roots, want)
+	}
+
+	t.Setenv(EnvDir, "/backup/a"+string(os.PathListSeparator)+"/backup/b")
+
+	roots, err = DefaultRoots()
+	if err != nil {
+		t.Fatalf("DefaultRoots: %v", err)
+	}
+	if want := []string{"/backup/a", "/backup/b"}; !reflect.DeepEqual(roots, want) {
+		t.Errorf("%s: got %v, want %v", EnvDir, roots, want)
+	}
+}
+
+func TestProjectsDir(t *testing.T) {
+	configDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(configDir, "projects"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := projectsDir(configDir), filepath.Join(configDir, "projects"); got != want {
+		t.Errorf("config dir: got %q, want %q", got, want)
+	}
+
+	exported := t.TempDir()
+	writeFile(t, filepath.Join(exported, "-src-app", "s1.jsonl"))
+	if got := projectsDir(exported); got != exported {
+		t.Errorf("exported projects dir: got %q, want %q", got, exported)
+	}
+
+	// A config directory without projects is not read as one
+	fresh := t.TempDir()
+	writeFile(t, filepath.Join(fresh, "history.jsonl"))
+	writeFile(t, filepath.Join(fresh, "todos", "s1-agent-s1.json"))
+	if got := projectsDir(fresh); got != "" {
+		t.Errorf("config dir without projects: got %q, want \"\"", got)
+	}
+
+	// As the default ~/.claude it is skipped
+	home := t.TempDir()
+	if err := os.Rename(fresh, filepath.Join(home, ".claude")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv(EnvDir, "")
+	t.Setenv(EnvConfigDir, "")
+	reader, err := NewReader(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Detect() {
+		t.Error("Detect: got true for a default config dir without projects")
+	}
+}
+
+func TestExplicitRootWithoutData(t *testing.T) {
+	fresh := t.TempDir()
+	writeFile(t, filepath.Join(fresh, "history.jsonl"))
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	for _, tc := range []struct {
+		name string
+		opts Options
+		env  string
+		want string
+	}{
+		{name: "flag without projects", opts: Options{Roots: []string{fresh}}, want: "neither a Claude config directory"},
+		{name: "missing flag", opts: Options{Roots: []string{missing}}, want: "no such file"},
+		{name: "missing env", env: missing, want: "no such file"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(EnvDir, tc.env)
+
+			reader, err := NewReader(tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reader.Detect() {
+				t.Error("Detect: got false for a root chosen by the user")
+			}
+
+			var readErr error
+			for _, err := range reader.Sessions() {
+				readErr = err
+			}
+			if readErr == nil || !strings.Contains(readErr.Error(), tc.want) {
+				t.Errorf("Sessions: got error %v, want one containing %q", readErr, tc.want)
+			}
+		})
+	}
+}
+
+// writeFile creates an empty file at path, along with its directory
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
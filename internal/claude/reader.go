@@ -2,42 +2,122 @@ package claude
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
-	"github.com/block/braindump/internal/model"
+	"github.com/block/braindump/cache"
+	"github.com/block/braindump/diagnostics"
+	"github.com/block/braindump/internal/timestamp"
+	"github.com/block/braindump/model"
 )
 
+// Options configures a Claude reader
+type Options struct {
+	// Roots are the Claude data directories to read. When empty, DefaultRoots is used.
+	// Roots given here or in the environment must hold Claude data; the
+	// default ~/.claude is skipped when it does not.
+	Roots []string
+	// Jobs is the number of session files parsed concurrently.
+	// Zero or less uses GOMAXPROCS.
+	Jobs int
+	// Cache stores parsed files between runs. Nil disables caching.
+	Cache *cache.Cache
+	// Events reads system, progress and file history snapshot records into
+	// each session's Events
+	Events bool
+	// Diagnostics collects malformed records and files that could not be
+	// read. Nil discards them.
+	Diagnostics *diagnostics.Collector
+}
+
 // Reader handles reading Claude session files
 type Reader struct {
-	homeDir string
+	roots []string
+	// explicit is set when the user chose the roots, which must then exist
+	explicit    bool
+	jobs        int
+	cache       *cache.Cache
+	events      bool
+	diagnostics *diagnostics.Collector
 }
 
 // NewReader creates a new Claude reader
-func NewReader() (*Reader, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+func NewReader(opts Options) (*Reader, error) {
+	roots := opts.Roots
+	if len(roots) == 0 {
+		roots = envRoots()
+	}
+	explicit := len(roots) > 0
+	if !explicit {
+		// Without a home directory there is no default to read
+		roots, _ = DefaultRoots()
 	}
-	return &Reader{homeDir: homeDir}, nil
+
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+
+	return &Reader{roots: roots, explicit: explicit, jobs: jobs, cache: opts.Cache, events: opts.Events, diagnostics: opts.Diagnostics}, nil
+}
+
+// Name returns the agent type produced by this reader
+func (r *Reader) Name() string {
+	return "claude"
 }
 
-// ReadSessions reads all Claude sessions
-func (r *Reader) ReadSessions() ([]model.Session, error) {
-	claudeDir := filepath.Join(r.homeDir, ".claude", "projects")
+// Detect reports whether any Claude projects directory exists. Roots chosen
+// by the user are always read, so that missing ones are reported.
+func (r *Reader) Detect() bool {
+	if r.explicit {
+		return true
+	}
+	for _, root := range r.roots {
+		if projectsDir(root) != "" {
+			return true
+		}
+	}
+	return false
+}
 
+// Sessions yields Claude sessions one file at a time
+func (r *Reader) Sessions() iter.Seq2[model.Session, error] {
+	return func(yield func(model.Session, error) bool) {
+		for _, root := range r.roots {
+			dir := projectsDir(root)
+			if dir == "" && r.explicit {
+				yield(model.Session{}, noDataError(root))
+				return
+			}
+			if dir == "" {
+				continue
+			}
+			if !r.walkRoot(dir, yield) {
+				return
+			}
+		}
+	}
+}
+
+// walkRoot yields the sessions found under a single projects directory.
+// It returns false once the caller has stopped iterating or an error was yielded.
+func (r *Reader) walkRoot(claudeDir string, yield func(model.Session, error) bool) bool {
 	// Check if directory exists
 	if _, err := os.Stat(claudeDir); os.IsNotExist(err) {
-		return []model.Session{}, nil // No Claude sessions
+		return true // No Claude sessions
 	}
 
-	var sessions []model.Session
+	var paths []string
 
-	// Walk through all project directories
+	// Walk through all project directories (in lexical order)
 	err := filepath.Walk(claudeDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -49,96 +129,91 @@ func (r *Reader) ReadSessions() ([]model.Session, error) {
 			if strings.Contains(path, "subagents") {
 				return nil
 			}
-
-			session, err := r.readSessionFile(path)
-			if err != nil {
-				// Log error but continue processing other sessions
-				fmt.Fprintf(os.Stderr, "Warning: failed to read session %s: %v\n", path, err)
-				return nil
-			}
-			if session != nil {
-				sessions = append(sessions, *session)
-			}
+			paths = append(paths, path)
 		}
 		return nil
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk Claude directory: %w", err)
+		yield(model.Session{}, fmt.Errorf("failed to walk Claude directory %s: %w", claudeDir, err))
+		return false
 	}
 
-	return sessions, nil
+	return r.parseFiles(paths, yield)
 }
 
-// readSessionFile reads a single Claude session file
-func (r *Reader) readSessionFile(path string) (*model.Session, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+// parseFiles parses session files with a bounded pool of workers and yields
+// the sessions in the order of paths, so output is deterministic regardless of
+// the number of jobs. At most 2*jobs parsed sessions are held in memory
+// waiting to be yielded. It returns false if the caller stopped iterating.
+func (r *Reader) parseFiles(paths []string, yield func(model.Session, error) bool) bool {
+	type result struct {
+		session *model.Session
+		err     error
 	}
-	defer file.Close()
-
-	var messages []model.Message
-	var sessionID string
-	var createdAt, updatedAt time.Time
-	var metadata model.SessionMetadata
-
-	scanner := bufio.NewScanner(file)
-	// Increase buffer size for large lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-
-		var raw map[string]any
-		if err := json.Unmarshal(line, &raw); err != nil {
-			// Skip malformed lines
-			continue
-		}
+	results := make([]chan result, len(paths))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
 
-		// Extract session metadata from first message
-		if sessionID == "" {
-			if sid, ok := raw["sessionId"].(string); ok {
-				sessionID = sid
+	work := make(chan int)
+	window := make(chan struct{}, 2*r.jobs)
+	done := make(chan struct{})
+	defer close(done)
+
+	// Feed work in order, never getting more than the window ahead of the consumer
+	go func() {
+		defer close(work)
+		for i := range paths {
+			select {
+			case window <- struct{}{}:
+			case <-done:
+				return
 			}
-			if cwd, ok := raw["cwd"].(string); ok {
-				metadata.WorkingDir = cwd
-			}
-			if branch, ok := raw["gitBranch"].(string); ok {
-				metadata.GitBranch = branch
+			select {
+			case work <- i:
+			case <-done:
+				return
 			}
 		}
+	}()
 
-		// Parse timestamp
-		if tsStr, ok := raw["timestamp"].(string); ok {
-			ts, _ := time.Parse(time.RFC3339, tsStr)
-			if createdAt.IsZero() || ts.Before(createdAt) {
-				createdAt = ts
-			}
-			if updatedAt.IsZero() || ts.After(updatedAt) {
-				updatedAt = ts
+	for range r.jobs {
+		go func() {
+			for i := range work {
+				session, err := r.readSessionFile(paths[i])
+				results[i] <- result{session: session, err: err}
 			}
-		}
+		}()
+	}
 
-		// Parse message
-		msgType, _ := raw["type"].(string)
-		if msgType == "user" || msgType == "assistant" {
-			msg := parseMessage(raw)
-			if msg != nil {
-				messages = append(messages, *msg)
-			}
+	for i, path := range paths {
+		res := <-results[i]
+		<-window
+
+		if res.err != nil {
+			// Record the error but continue processing other sessions
+			r.report(model.Diagnostic{File: path, Kind: model.DiagnosticReadFailed, Message: res.err.Error()})
+			continue
+		}
+		if res.session != nil && !yield(*res.session, nil) {
+			return false
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+	return true
+}
+
+// readSessionFile reads a single Claude session file
+func (r *Reader) readSessionFile(path string) (*model.Session, error) {
+	state, err := r.parseFile(path)
+	if err != nil {
+		return nil, err
 	}
 
 	// If no sessionID found, use filename
+	sessionID := state.SessionID
 	if sessionID == "" {
 		sessionID = strings.TrimSuffix(filepath.Base(path), ".jsonl")
 	}
@@ -146,18 +221,26 @@ func (r *Reader) readSessionFile(path string) (*model.Session, error) {
 	// Read subagents
 	subagents, err := r.readSubagents(filepath.Dir(path), sessionID)
 	if err != nil {
-		// Log but don't fail
-		fmt.Fprintf(os.Stderr, "Warning: failed to read subagents for %s: %v\n", sessionID, err)
+		// Record but don't fail
+		r.report(model.Diagnostic{
+			File:      filepath.Join(filepath.Dir(path), sessionID, "subagents"),
+			SessionID: sessionID,
+			Kind:      model.DiagnosticReadFailed,
+			Message:   err.Error(),
+		})
 	}
+	linkSubagents(subagents, state.Messages, state.AgentToolUses)
 
 	return &model.Session{
 		AgentType: "claude",
 		SessionID: sessionID,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-		Metadata:  metadata,
-		Messages:  messages,
+		CreatedAt: state.CreatedAt,
+		UpdatedAt: state.UpdatedAt,
+		Metadata:  state.Metadata,
+		Messages:  state.Messages,
 		Subagents: subagents,
+		Branches:  newConversationTree(state.Messages, state.Links).branches(),
+		Events:    state.Events,
 	}, nil
 }
 
@@ -181,16 +264,17 @@ func (r *Reader) readSubagents(sessionDir, sessionID string) ([]model.Subagent,
 			agentID := strings.TrimPrefix(strings.TrimSuffix(file.Name(), ".jsonl"), "agent-")
 
 			path := filepath.Join(subagentsDir, file.Name())
-			messages, slug, err := r.readSubagentFile(path)
+			state, err := r.parseFile(path)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to read subagent %s: %v\n", agentID, err)
+				r.report(model.Diagnostic{File: path, SessionID: sessionID, Kind: model.DiagnosticReadFailed, Message: err.Error()})
 				continue
 			}
 
 			subagents = append(subagents, model.Subagent{
 				AgentID:  agentID,
-				Slug:     slug,
-				Messages: messages,
+				Slug:     state.Slug,
+				Messages: state.Messages,
+				Events:   state.Events,
 			})
 		}
 	}
@@ -198,47 +282,268 @@ func (r *Reader) readSubagents(sessionDir, sessionID string) ([]model.Subagent,
 	return subagents, nil
 }
 
-// readSubagentFile reads a subagent session file
-func (r *Reader) readSubagentFile(path string) ([]model.Message, string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, "", err
-	}
-	defer file.Close()
+// report records a problem found while reading
+func (r *Reader) report(d model.Diagnostic) {
+	d.Agent = r.Name()
+	r.diagnostics.Add(d)
+}
 
-	var messages []model.Message
-	var slug string
+// reportFile records the problems found in the file at path
+func (r *Reader) reportFile(path string, state *fileState) {
+	for _, d := range state.Diagnostics {
+		d.File = path
+		r.report(d)
+	}
+}
 
-	scanner := bufio.NewScanner(file)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+// linkSubagents sets each subagent's ToolUseID to the Task tool_use that spawned it.
+// Claude records the subagent's agent ID on the Task tool result; for older
+// sessions without it, the subagent's first prompt is matched against the Task input.
+func linkSubagents(subagents []model.Subagent, messages []model.Message, agentToolUses map[string]string) {
+	prompts := make(map[string]string)
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type != "tool_use" || !isTaskTool(block.ToolName) {
+				continue
+			}
+			if prompt, ok := block.ToolInput["prompt"].(string); ok {
+				prompts[prompt] = block.ToolUseID
+			}
+		}
+	}
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
+	for i := range subagents {
+		subagent := &subagents[i]
+		if toolUseID, ok := agentToolUses[subagent.AgentID]; ok {
+			subagent.ToolUseID = toolUseID
 			continue
 		}
 
-		var raw map[string]any
-		if err := json.Unmarshal(line, &raw); err != nil {
+		if len(subagent.Messages) == 0 {
 			continue
 		}
+		for _, block := range subagent.Messages[0].Content {
+			if block.Type == "text" {
+				subagent.ToolUseID = prompts[block.Text]
+				break
+			}
+		}
+	}
+}
+
+// isTaskTool reports whether a tool spawns subagents
+func isTaskTool(name string) bool {
+	return name == "Task" || name == "Agent"
+}
+
+// fileState accumulates what has been parsed from a Claude JSONL file,
+// either a main session file or a subagent file. It is stored in the cache
+// and extended in place when new lines are appended to the file.
+type fileState struct {
+	SessionID string                `json:"session_id,omitempty"`
+	Slug      string                `json:"slug,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	Metadata  model.SessionMetadata `json:"metadata"`
+	Messages  []model.Message       `json:"messages,omitempty"`
+	// AgentToolUses maps subagent IDs to the Task tool_use that spawned them
+	AgentToolUses map[string]string `json:"agent_tool_uses,omitempty"`
+	// Links maps the UUIDs of records that are not messages to their parents
+	Links map[string]string `json:"links,omitempty"`
+	// Events are the records that are not messages, when events are read
+	Events []model.Event `json:"events,omitempty"`
+	// Lines counts the newline-terminated lines read so far
+	Lines int `json:"lines"`
+	// Diagnostics are the problems found in the file so far, without the
+	// file's path, which is added when they are reported
+	Diagnostics []model.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// readFrom parses lines from file starting at offset into the state,
+// including events if requested. It returns the offset just past the last
+// newline-terminated line, and whether the file ended on a line boundary
+// (no partially written line).
+func (s *fileState) readFrom(file *os.File, offset int64, events bool) (int64, bool, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, false, fmt.Errorf("failed to seek: %w", err)
+	}
 
-		// Extract slug from first message
-		if slug == "" {
-			if s, ok := raw["slug"].(string); ok {
-				slug = s
+	reader := bufio.NewReaderSize(file, 64*1024)
+	complete := true
+
+	for {
+		// Lines are read whole however long they are; a single record can
+		// carry a large tool result or an inline image
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			terminated := line[len(line)-1] == '\n'
+			number := s.Lines + 1
+			if terminated {
+				offset += int64(len(line))
+				s.Lines++
+			} else {
+				complete = false
 			}
+			s.processLine(line, number, terminated, events)
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return offset, false, fmt.Errorf("error reading file: %w", err)
 		}
+	}
+
+	return offset, complete, nil
+}
+
+// processLine parses line number and folds its record into the state.
+// Malformed lines are skipped and remembered, unless they are the last line
+// and not yet terminated, which usually means they are still being written.
+func (s *fileState) processLine(line []byte, number int, terminated, events bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(line, &raw); err != nil {
+		if terminated {
+			s.Diagnostics = append(s.Diagnostics, model.Diagnostic{Line: number, Kind: model.DiagnosticMalformedRecord, Message: err.Error()})
+		}
+		return
+	}
+
+	s.processRecord(raw, number, events)
+}
+
+// processRecord folds the JSONL record on line number into the state
+func (s *fileState) processRecord(raw map[string]any, number int, events bool) {
+	// Extract session metadata from first message
+	if s.SessionID == "" {
+		if sid, ok := raw["sessionId"].(string); ok {
+			s.SessionID = sid
+		}
+		if cwd, ok := raw["cwd"].(string); ok {
+			s.Metadata.WorkingDir = cwd
+		}
+		if branch, ok := raw["gitBranch"].(string); ok {
+			s.Metadata.GitBranch = branch
+		}
+	}
+
+	// Extract slug (set on subagent files)
+	if s.Slug == "" {
+		if slug, ok := raw["slug"].(string); ok {
+			s.Slug = slug
+		}
+	}
 
-		msgType, _ := raw["type"].(string)
-		if msgType == "user" || msgType == "assistant" {
-			msg := parseMessage(raw)
-			if msg != nil {
-				messages = append(messages, *msg)
+	// Parse timestamp
+	if tsStr, ok := raw["timestamp"].(string); ok {
+		ts, err := timestamp.Parse(tsStr)
+		if err != nil {
+			s.Diagnostics = append(s.Diagnostics, model.Diagnostic{
+				Line:    number,
+				Field:   "timestamp",
+				Kind:    model.DiagnosticInvalidTimestamp,
+				Message: err.Error(),
+			})
+		} else {
+			if s.CreatedAt.IsZero() || ts.Before(s.CreatedAt) {
+				s.CreatedAt = ts
 			}
+			if s.UpdatedAt.IsZero() || ts.After(s.UpdatedAt) {
+				s.UpdatedAt = ts
+			}
+		}
+	}
+
+	// Parse message
+	msgType, _ := raw["type"].(string)
+	switch msgType {
+	case "user", "assistant":
+		msg := parseMessage(raw)
+		if msg != nil {
+			s.Messages = append(s.Messages, *msg)
+			s.recordAgentToolUse(raw, msg)
+			return
+		}
+
+	case "summary":
+		s.recordSummary(raw)
+		return
+
+	case "system":
+		if subtype, _ := raw["subtype"].(string); subtype == "compact_boundary" {
+			s.Messages = append(s.Messages, parseCompactBoundary(raw))
+			return
+		}
+	}
+
+	s.recordLink(raw)
+
+	if events {
+		if event := parseEvent(raw); event != nil {
+			s.Events = append(s.Events, *event)
 		}
 	}
+}
+
+// recordSummary keeps a conversation summary, replacing any earlier summary
+// of the same conversation
+func (s *fileState) recordSummary(raw map[string]any) {
+	text, _ := raw["summary"].(string)
+	leafUUID, _ := raw["leafUuid"].(string)
+	if text == "" {
+		return
+	}
 
-	return messages, slug, scanner.Err()
+	summary := model.Summary{Summary: text, LeafUUID: leafUUID}
+	for i, existing := range s.Metadata.Summaries {
+		if leafUUID != "" && existing.LeafUUID == leafUUID {
+			s.Metadata.Summaries[i] = summary
+			return
+		}
+	}
+	s.Metadata.Summaries = append(s.Metadata.Summaries, summary)
+}
+
+// recordLink remembers the parent of a record that is not a message, so the
+// conversation tree can be followed through it
+func (s *fileState) recordLink(raw map[string]any) {
+	uuid, _ := raw["uuid"].(string)
+	parentUUID, _ := raw["parentUuid"].(string)
+	if uuid == "" || parentUUID == "" {
+		return
+	}
+
+	if s.Links == nil {
+		s.Links = make(map[string]string)
+	}
+	s.Links[uuid] = parentUUID
+}
+
+// recordAgentToolUse remembers which Task tool_use a subagent result belongs to.
+// Claude stores the subagent's ID in the record's toolUseResult.
+func (s *fileState) recordAgentToolUse(raw map[string]any, msg *model.Message) {
+	result, ok := raw["toolUseResult"].(map[string]any)
+	if !ok {
+		return
+	}
+	agentID, ok := result["agentId"].(string)
+	if !ok || agentID == "" {
+		return
+	}
+
+	for _, block := range msg.Content {
+		if block.Type == "tool_result" && block.ToolUseID != "" {
+			if s.AgentToolUses == nil {
+				s.AgentToolUses = make(map[string]string)
+			}
+			s.AgentToolUses[agentID] = block.ToolUseID
+			return
+		}
+	}
 }
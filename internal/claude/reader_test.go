@@ -0,0 +1,282 @@
+package claude
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/block/braindump/model"
+	"github.com/block/braindump/source"
+)
+
+// writeCorpus writes a synthetic Claude projects directory with the given
+// number of session files, each holding messagesPerFile user/assistant turns.
+func writeCorpus(tb testing.TB, dir string, files, messagesPerFile int) {
+	tb.Helper()
+
+	for i := range files {
+		projectDir := filepath.Join(dir, fmt.Sprintf("-project-%02d", i%20))
+		if err := os.MkdirAll(projectDir, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+
+		sessionID := fmt.Sprintf("session-%05d", i)
+
+		var b strings.Builder
+		parent := ""
+		for j := range messagesPerFile {
+			role := "user"
+			content := fmt.Sprintf(`"Please look at file %d of session %d"`, j, i)
+			if j%2 == 1 {
+				role = "assistant"
+				content = fmt.Sprintf(`[{"type":"text","text":"Reading it now"},{"type":"tool_use","id":"tool-%d","name":"Read","input":{"file_path":"/src/file%d.go"}}]`, j, j)
+			}
+
+			uuid := fmt.Sprintf("%s-%d", sessionID, j)
+			fmt.Fprintf(&b, `{"type":%q,"sessionId":%q,"uuid":%q,"parentUuid":%q,"cwd":"/src","timestamp":"2026-02-07T12:%02d:%02dZ","message":{"role":%q,"content":%s,"usage":{"input_tokens":10,"output_tokens":5}}}`+"\n",
+				role, sessionID, uuid, parent, j/60%60, j%60, role, content)
+			parent = uuid
+		}
+
+		path := filepath.Join(projectDir, sessionID+".jsonl")
+		if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func readAll(tb testing.TB, dir string, jobs int) []model.Session {
+	tb.Helper()
+
+	reader, err := NewReader(Options{Roots: []string{dir}, Jobs: jobs})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	sessions, err := source.ReadAll(reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return sessions
+}
+
+func TestSessionsDeterministicOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeCorpus(t, dir, 60, 4)
+
+	serial := readAll(t, dir, 1)
+	parallel := readAll(t, dir, 8)
+
+	if len(serial) != 60 || len(parallel) != 60 {
+		t.Fatalf("Expected 60 sessions, got %d serial and %d parallel", len(serial), len(parallel))
+	}
+
+	for i := range serial {
+		if serial[i].SessionID != parallel[i].SessionID {
+			t.Fatalf("Session %d: serial %q, parallel %q", i, serial[i].SessionID, parallel[i].SessionID)
+		}
+	}
+}
+
+func TestSessionsStopEarly(t *testing.T) {
+	dir := t.TempDir()
+	writeCorpus(t, dir, 30, 2)
+
+	reader, err := NewReader(Options{Roots: []string{dir}, Jobs: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for _, err := range reader.Sessions() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+		if count == 5 {
+			break
+		}
+	}
+
+	if count != 5 {
+		t.Errorf("Expected to stop after 5 sessions, got %d", count)
+	}
+}
+
+func BenchmarkReadSessions(b *testing.B) {
+	dir := b.TempDir()
+	writeCorpus(b, dir, 2000, 40)
+
+	for _, jobs := range []int{1, 0} {
+		name := fmt.Sprintf("jobs=%d", jobs)
+		if jobs == 0 {
+			name = "jobs=GOMAXPROCS"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				readAll(b, dir, jobs)
+			}
+		})
+	}
+}
+
+func TestLinkSubagents(t *testing.T) {
+	messages := []model.Message{
+		{
+			Role: "assistant",
+			Content: []model.ContentBlock{
+				{Type: "tool_use", ToolName: "Task", ToolUseID: "tool-1", ToolInput: map[string]any{"prompt": "Find the migration"}},
+				{Type: "tool_use", ToolName: "Task", ToolUseID: "tool-2", ToolInput: map[string]any{"prompt": "Review the tests"}},
+			},
+		},
+	}
+
+	subagents := []model.Subagent{
+		{AgentID: "by-result"},
+		{
+			AgentID:  "by-prompt",
+			Messages: []model.Message{{Role: "user", Content: []model.ContentBlock{{Type: "text", Text: "Review the tests"}}}},
+		},
+		{AgentID: "orphan"},
+	}
+
+	linkSubagents(subagents, messages, map[string]string{"by-result": "tool-1"})
+
+	for i, want := range []string{"tool-1", "tool-2", ""} {
+		if got := subagents[i].ToolUseID; got != want {
+			t.Errorf("%s: ToolUseID got %q, want %q", subagents[i].AgentID, got, want)
+		}
+	}
+}
+
+func TestReadSessionFileCompaction(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.jsonl")
+	lines := strings.Join([]string{
+		`{"type":"summary","summary":"Draft","leafUuid":"a1"}`,
+		`{"type":"summary","summary":"Fix the flaky migration test","leafUuid":"a1"}`,
+		`{"type":"user","sessionId":"s1","uuid":"u1","timestamp":"2026-02-07T12:00:00Z","message":{"role":"user","content":"fix the test"}}`,
+		`{"type":"assistant","sessionId":"s1","uuid":"a1","parentUuid":"u1","timestamp":"2026-02-07T12:00:01Z","message":{"role":"assistant","content":"done"}}`,
+		`{"type":"system","subtype":"compact_boundary","sessionId":"s1","uuid":"c1","parentUuid":null,"logicalParentUuid":"a1","timestamp":"2026-02-07T12:30:00Z","content":"Conversation compacted","level":"info","compactMetadata":{"trigger":"auto","preTokens":155000}}`,
+		`{"type":"user","sessionId":"s1","uuid":"u2","parentUuid":"c1","isCompactSummary":true,"isVisibleInTranscriptOnly":true,"timestamp":"2026-02-07T12:30:00Z","message":{"role":"user","content":"This session is being continued from a previous conversation..."}}`,
+	}, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(lines), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(Options{Roots: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := reader.readSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []model.Summary{{Summary: "Fix the flaky migration test", LeafUUID: "a1"}}; !reflect.DeepEqual(session.Metadata.Summaries, want) {
+		t.Errorf("summaries = %+v, want %+v", session.Metadata.Summaries, want)
+	}
+
+	if len(session.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(session.Messages))
+	}
+	boundary := session.Messages[2]
+	want := model.ContentBlock{Type: "compact_boundary", Text: "Conversation compacted", Compaction: &model.Compaction{Trigger: "auto", PreTokens: 155000}}
+	if boundary.Role != "system" || boundary.ParentUUID != "a1" || !reflect.DeepEqual(boundary.Content, []model.ContentBlock{want}) {
+		t.Errorf("unexpected compact boundary: %+v", boundary)
+	}
+	if summary := session.Messages[3]; !summary.Metadata.IsCompactSummary || !summary.Metadata.VisibleToUser() {
+		t.Errorf("expected a visible compact summary message, got %+v", summary.Metadata)
+	}
+
+	// The boundary continues the conversation rather than starting a branch
+	if session.Branches != nil {
+		t.Errorf("expected a linear conversation, got branches %+v", session.Branches)
+	}
+}
+
+func TestReadSessionFileEvents(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.jsonl")
+	lines := strings.Join([]string{
+		`{"type":"file-history-snapshot","messageId":"u1","snapshot":{"messageId":"u1","trackedFileBackups":{},"timestamp":"2026-02-07T12:00:00Z"},"isSnapshotUpdate":false}`,
+		`{"type":"user","sessionId":"s1","uuid":"u1","timestamp":"2026-02-07T12:00:00Z","message":{"role":"user","content":"run the tests"}}`,
+		`{"type":"system","subtype":"api_error","sessionId":"s1","uuid":"e1","parentUuid":"u1","timestamp":"2026-02-07T12:00:02Z","level":"error","retryAttempt":1,"retryInMs":500}`,
+		`{"type":"progress","sessionId":"s1","uuid":"p1","parentUuid":"e1","timestamp":"2026-02-07T12:00:03Z","toolUseID":"tool-1","data":{"type":"hook_progress","hookEvent":"PreToolUse"}}`,
+		`{"type":"assistant","sessionId":"s1","uuid":"a1","parentUuid":"p1","timestamp":"2026-02-07T12:00:04Z","message":{"role":"assistant","content":"done"}}`,
+	}, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(lines), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Subagents keep their own events
+	subagentsDir := filepath.Join(dir, "s1", "subagents")
+	if err := os.MkdirAll(subagentsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	subagent := strings.Join([]string{
+		`{"type":"user","sessionId":"s1","agentId":"x1","uuid":"su1","timestamp":"2026-02-07T12:00:05Z","message":{"role":"user","content":"find it"}}`,
+		`{"type":"system","subtype":"api_error","sessionId":"s1","agentId":"x1","uuid":"se1","parentUuid":"su1","timestamp":"2026-02-07T12:00:06Z","level":"error"}`,
+	}, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(subagentsDir, "agent-x1.jsonl"), []byte(subagent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(Options{Roots: []string{dir}, Events: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := reader.readSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.Event{
+		{
+			Kind:      model.EventFileHistorySnapshot,
+			Timestamp: time.Date(2026, 2, 7, 12, 0, 0, 0, time.UTC),
+			Data: map[string]any{
+				"messageId":        "u1",
+				"snapshot":         map[string]any{"messageId": "u1", "trackedFileBackups": map[string]any{}, "timestamp": "2026-02-07T12:00:00Z"},
+				"isSnapshotUpdate": false,
+			},
+		},
+		{
+			Kind: model.EventSystem, Subtype: "api_error", Level: "error", UUID: "e1",
+			Timestamp: time.Date(2026, 2, 7, 12, 0, 2, 0, time.UTC),
+			Data:      map[string]any{"retryAttempt": float64(1), "retryInMs": float64(500)},
+		},
+		{
+			Kind: model.EventProgress, Subtype: "hook_progress", UUID: "p1", ToolUseID: "tool-1",
+			Timestamp: time.Date(2026, 2, 7, 12, 0, 3, 0, time.UTC),
+			Data:      map[string]any{"data": map[string]any{"type": "hook_progress", "hookEvent": "PreToolUse"}},
+		},
+	}
+	if !reflect.DeepEqual(session.Events, want) {
+		t.Errorf("events = %+v, want %+v", session.Events, want)
+	}
+	if len(session.Messages) != 2 || session.Branches != nil {
+		t.Errorf("expected 2 messages on one branch, got %d messages and branches %+v", len(session.Messages), session.Branches)
+	}
+	if len(session.Subagents) != 1 || len(session.Subagents[0].Events) != 1 || session.Subagents[0].Events[0].UUID != "se1" {
+		t.Errorf("expected the subagent's api_error event, got %+v", session.Subagents)
+	}
+
+	// Events are only read when requested
+	reader, err = NewReader(Options{Roots: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err = reader.readSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Events != nil || len(session.Subagents) != 1 || session.Subagents[0].Events != nil {
+		t.Errorf("expected no events, got %+v and subagents %+v", session.Events, session.Subagents)
+	}
+}
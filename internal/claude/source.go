@@ -0,0 +1,24 @@
+package claude
+
+import (
+	"github.com/block/braindump/source"
+	"github.com/spf13/pflag"
+)
+
+// Register adds the Claude source to registry, with the flags that choose
+// which directories it reads and whether it reads events
+func Register(registry *source.Registry) {
+	var opts Options
+
+	registry.Register("claude", func(cfg source.Config) (source.Source, error) {
+		opts := opts
+		opts.Jobs = cfg.Jobs
+		opts.Cache = cfg.Cache
+		opts.Diagnostics = cfg.Diagnostics
+		return NewReader(opts)
+	})
+	registry.RegisterFlags("claude", func(fs *pflag.FlagSet) {
+		fs.StringSliceVar(&opts.Roots, "claude-dir", nil, "Claude data directory to read (repeatable; default: $"+EnvDir+", $"+EnvConfigDir+" or ~/.claude)")
+		fs.BoolVar(&opts.Events, "include-events", false, "Include system, progress and file history snapshot events (Claude only)")
+	})
+}
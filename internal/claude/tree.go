@@ -0,0 +1,141 @@
+package claude
+
+import (
+	"slices"
+
+	"github.com/block/braindump/model"
+)
+
+// conversationTree links messages to their parents through parentUuid.
+// Claude appends every message to the session file, so rewinding (Esc-Esc)
+// or editing a prompt starts a new branch from an earlier message while the
+// abandoned messages stay in the file.
+type conversationTree struct {
+	messages []model.Message
+	// index maps message UUIDs to their first position in messages
+	index map[string]int
+	// links maps the UUIDs of records that are not messages (system and
+	// progress events) to their parents, so chains can be followed through them
+	links map[string]string
+}
+
+// newConversationTree builds the tree of messages
+func newConversationTree(messages []model.Message, links map[string]string) *conversationTree {
+	index := make(map[string]int, len(messages))
+	for i, msg := range messages {
+		if _, ok := index[msg.UUID]; msg.UUID != "" && !ok {
+			index[msg.UUID] = i
+		}
+	}
+	return &conversationTree{messages: messages, index: index, links: links}
+}
+
+// parent returns the UUID of the closest ancestor of uuid that is a message,
+// or "" for a root
+func (t *conversationTree) parent(uuid string) string {
+	p := t.messages[t.index[uuid]].ParentUUID
+
+	// Bounded by the number of links, in case they form a cycle
+	for range len(t.links) + 1 {
+		if p == "" {
+			break
+		}
+		if _, ok := t.index[p]; ok {
+			return p
+		}
+		p = t.links[p]
+	}
+	return ""
+}
+
+// path returns the messages from the root to uuid
+func (t *conversationTree) path(uuid string) []string {
+	var path []string
+
+	// Bounded by the number of messages, in case parents form a cycle
+	for p := uuid; p != "" && len(path) < len(t.index); p = t.parent(p) {
+		path = append(path, p)
+	}
+
+	slices.Reverse(path)
+	return path
+}
+
+// branches returns every path through the tree, or nil when the conversation
+// is linear. The active branch ends at the most recently written leaf outside
+// a sidechain; it is the one the conversation continued on. Leaves reached only
+// through tool results are not branches: parallel tool results can each point
+// at their own tool_use rather than continuing a single chain.
+func (t *conversationTree) branches() []model.Branch {
+	hasChild := make(map[string]bool)
+	for uuid := range t.index {
+		if p := t.parent(uuid); p != "" {
+			hasChild[p] = true
+		}
+	}
+
+	var leaves []string
+	active := ""
+	for i, msg := range t.messages {
+		if msg.UUID == "" || t.index[msg.UUID] != i || hasChild[msg.UUID] {
+			continue
+		}
+		leaves = append(leaves, msg.UUID)
+		if !msg.Metadata.IsSidechain {
+			active = msg.UUID
+		}
+	}
+	if len(leaves) < 2 {
+		return nil
+	}
+	if active == "" {
+		active = leaves[len(leaves)-1]
+	}
+
+	activePath := t.path(active)
+	onActive := make(map[string]bool, len(activePath))
+	for _, uuid := range activePath {
+		onActive[uuid] = true
+	}
+
+	branches := []model.Branch{{LeafUUID: active, Active: true, MessageUUIDs: activePath}}
+	for _, leaf := range leaves {
+		if leaf == active {
+			continue
+		}
+
+		branch := model.Branch{LeafUUID: leaf, MessageUUIDs: t.path(leaf)}
+		diverged := 0
+		for i, uuid := range branch.MessageUUIDs {
+			if onActive[uuid] {
+				branch.ForkUUID = uuid
+				diverged = i + 1
+			}
+		}
+		if t.toolResultsOnly(branch.MessageUUIDs[diverged:]) {
+			continue
+		}
+		branches = append(branches, branch)
+	}
+
+	if len(branches) < 2 {
+		return nil
+	}
+	return branches
+}
+
+// toolResultsOnly reports whether every message carries nothing but tool results
+func (t *conversationTree) toolResultsOnly(uuids []string) bool {
+	for _, uuid := range uuids {
+		msg := t.messages[t.index[uuid]]
+		if len(msg.Content) == 0 {
+			return false
+		}
+		for _, block := range msg.Content {
+			if block.Type != "tool_result" {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package claude
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/block/braindump/model"
+)
+
+// treeMessage builds a text or tool result message for tree tests
+func treeMessage(uuid, parent, blockType string) model.Message {
+	return model.Message{UUID: uuid, ParentUUID: parent, Content: []model.ContentBlock{{Type: blockType}}}
+}
+
+func TestConversationTreeBranches(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []model.Message
+		links    map[string]string
+		want     []model.Branch
+	}{
+		{
+			name: "linear",
+			messages: []model.Message{
+				treeMessage("u1", "", "text"),
+				treeMessage("a1", "u1", "text"),
+			},
+		},
+		{
+			name: "rewound prompt",
+			messages: []model.Message{
+				treeMessage("u1", "", "text"),
+				treeMessage("a1", "u1", "text"),
+				treeMessage("u2", "a1", "text"),
+				treeMessage("a2", "u2", "text"),
+				// Esc-Esc back to a1 and a different prompt
+				treeMessage("u3", "a1", "text"),
+				treeMessage("a3", "u3", "text"),
+			},
+			want: []model.Branch{
+				{LeafUUID: "a3", Active: true, MessageUUIDs: []string{"u1", "a1", "u3", "a3"}},
+				{LeafUUID: "a2", ForkUUID: "a1", MessageUUIDs: []string{"u1", "a1", "u2", "a2"}},
+			},
+		},
+		{
+			name: "chain through system records",
+			messages: []model.Message{
+				treeMessage("u1", "", "text"),
+				treeMessage("a1", "s1", "text"),
+				treeMessage("u2", "a1", "text"),
+				treeMessage("a2", "s2", "text"),
+			},
+			links: map[string]string{"s1": "u1", "s2": "a1"},
+			want: []model.Branch{
+				{LeafUUID: "a2", Active: true, MessageUUIDs: []string{"u1", "a1", "a2"}},
+				{LeafUUID: "u2", ForkUUID: "a1", MessageUUIDs: []string{"u1", "a1", "u2"}},
+			},
+		},
+		{
+			name: "parallel tool results",
+			messages: []model.Message{
+				treeMessage("u1", "", "text"),
+				treeMessage("t1", "u1", "tool_use"),
+				treeMessage("t2", "t1", "tool_use"),
+				treeMessage("r1", "t1", "tool_result"),
+				treeMessage("r2", "t2", "tool_result"),
+				treeMessage("a1", "r2", "text"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newConversationTree(tt.messages, tt.links).branches()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("branches =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
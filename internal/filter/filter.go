@@ -4,7 +4,19 @@ import (
 	"strings"
 	"time"
 
-	"github.com/block/braindump/internal/model"
+	"github.com/block/braindump/model"
+)
+
+// Audiences a message can be visible to
+const (
+	VisibleToUser  = "user"
+	VisibleToAgent = "agent"
+)
+
+// Branch selections for conversations that were rewound or forked
+const (
+	BranchActive = "active"
+	BranchAll    = "all"
 )
 
 // Options contains filtering options
@@ -13,6 +25,15 @@ type Options struct {
 	SessionID string
 	Since     time.Time
 	Until     time.Time
+	// StripThinking removes thinking and redacted_thinking blocks from messages
+	StripThinking bool
+	// VisibleTo keeps only the messages shown to the user (VisibleToUser) or
+	// sent to the model (VisibleToAgent). Empty keeps every message.
+	VisibleTo string
+	// Branch selects which branches of a rewound or forked conversation are
+	// kept: BranchActive drops messages that are only on abandoned branches.
+	// Empty or BranchAll keeps every message.
+	Branch string
 }
 
 // Apply applies filters to sessions
@@ -21,13 +42,135 @@ func Apply(sessions []model.Session, opts Options) []model.Session {
 
 	for _, session := range sessions {
 		if shouldInclude(session, opts) {
-			filtered = append(filtered, session)
+			filtered = append(filtered, Content(session, opts))
 		}
 	}
 
 	return filtered
 }
 
+// Content removes the content excluded by the options from a session's
+// messages, including its subagents. The session passed in is not modified.
+func Content(session model.Session, opts Options) model.Session {
+	if !opts.StripThinking && opts.VisibleTo == "" && opts.Branch != BranchActive {
+		return session
+	}
+
+	if opts.Branch == BranchActive {
+		session.Messages = activeBranch(session.Messages, session.Branches)
+	}
+	session.Messages = content(session.Messages, opts)
+	if session.Subagents != nil {
+		subagents := make([]model.Subagent, len(session.Subagents))
+		for i, subagent := range session.Subagents {
+			subagent.Messages = content(subagent.Messages, opts)
+			subagents[i] = subagent
+		}
+		session.Subagents = subagents
+	}
+
+	return session
+}
+
+// content applies the content options to a list of messages
+func content(messages []model.Message, opts Options) []model.Message {
+	if opts.VisibleTo != "" {
+		messages = visibleTo(messages, opts.VisibleTo)
+	}
+	if opts.StripThinking {
+		messages = stripThinking(messages)
+	}
+	return messages
+}
+
+// activeBranch returns the messages that are not only on abandoned branches
+func activeBranch(messages []model.Message, branches []model.Branch) []model.Message {
+	if len(branches) == 0 {
+		return messages
+	}
+
+	active := make(map[string]bool)
+	for _, branch := range branches {
+		if branch.Active {
+			for _, uuid := range branch.MessageUUIDs {
+				active[uuid] = true
+			}
+		}
+	}
+
+	abandoned := make(map[string]bool)
+	for _, branch := range branches {
+		for _, uuid := range branch.MessageUUIDs {
+			if !active[uuid] {
+				abandoned[uuid] = true
+			}
+		}
+	}
+
+	var kept []model.Message
+	for _, msg := range messages {
+		if !abandoned[msg.UUID] {
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
+
+// visibleTo returns the messages visible to the audience
+func visibleTo(messages []model.Message, audience string) []model.Message {
+	var visible []model.Message
+
+	for _, msg := range messages {
+		switch {
+		case audience == VisibleToUser && !msg.Metadata.VisibleToUser():
+		case audience == VisibleToAgent && !msg.Metadata.VisibleToAgent():
+		default:
+			visible = append(visible, msg)
+		}
+	}
+
+	return visible
+}
+
+// stripThinking returns messages without thinking blocks, dropping messages
+// that contained nothing else
+func stripThinking(messages []model.Message) []model.Message {
+	var stripped []model.Message
+
+	for _, msg := range messages {
+		var content []model.ContentBlock
+		removed := false
+		for _, block := range msg.Content {
+			if isThinking(block) {
+				removed = true
+				continue
+			}
+			content = append(content, block)
+		}
+
+		if removed && len(content) == 0 {
+			continue
+		}
+		if removed {
+			msg.Content = content
+		}
+		stripped = append(stripped, msg)
+	}
+
+	return stripped
+}
+
+// isThinking reports whether a block holds model reasoning
+func isThinking(block model.ContentBlock) bool {
+	return block.Type == "thinking" || block.Type == "redacted_thinking"
+}
+
+// Match reports whether a single session passes the filters.
+// It is used when sessions are streamed rather than collected.
+func Match(session model.Session, opts Options) bool {
+	return shouldInclude(session, opts)
+}
+
 // shouldInclude checks if a session should be included based on filters
 func shouldInclude(session model.Session, opts Options) bool {
 	// Filter by agent type
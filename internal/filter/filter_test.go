@@ -1,10 +1,11 @@
 package filter
 
 import (
+	"slices"
 	"testing"
 	"time"
 
-	"github.com/block/braindump/internal/model"
+	"github.com/block/braindump/model"
 )
 
 func TestApply(t *testing.T) {
@@ -183,3 +184,108 @@ func TestShouldInclude(t *testing.T) {
 		})
 	}
 }
+
+func TestContentStripThinking(t *testing.T) {
+	session := model.Session{
+		SessionID: "session-1",
+		Messages: []model.Message{
+			{Role: "assistant", Content: []model.ContentBlock{{Type: "thinking", Thinking: "hmm"}}},
+			{Role: "assistant", Content: []model.ContentBlock{
+				{Type: "redacted_thinking", Data: "xyz"},
+				{Type: "text", Text: "Done"},
+			}},
+		},
+		Subagents: []model.Subagent{
+			{AgentID: "a1", Messages: []model.Message{
+				{Role: "assistant", Content: []model.ContentBlock{{Type: "thinking", Thinking: "sub"}}},
+				{Role: "user", Content: []model.ContentBlock{{Type: "text", Text: "go"}}},
+			}},
+		},
+	}
+
+	kept := Content(session, Options{})
+	if len(kept.Messages) != 2 || len(kept.Messages[1].Content) != 2 {
+		t.Errorf("without StripThinking the session should be unchanged, got %+v", kept.Messages)
+	}
+
+	stripped := Content(session, Options{StripThinking: true})
+
+	if len(stripped.Messages) != 1 {
+		t.Fatalf("expected thinking-only message to be dropped, got %d messages", len(stripped.Messages))
+	}
+	if content := stripped.Messages[0].Content; len(content) != 1 || content[0].Text != "Done" {
+		t.Errorf("unexpected content after stripping: %+v", content)
+	}
+	if len(stripped.Subagents[0].Messages) != 1 {
+		t.Errorf("expected subagent thinking to be stripped, got %+v", stripped.Subagents[0].Messages)
+	}
+
+	// The original session is not modified
+	if len(session.Messages) != 2 || len(session.Subagents[0].Messages) != 2 {
+		t.Error("Content modified the original session")
+	}
+}
+
+func TestContentVisibleTo(t *testing.T) {
+	hidden := false
+	session := model.Session{
+		SessionID: "session-1",
+		Messages: []model.Message{
+			{UUID: "typed", Role: "user"},
+			{UUID: "injected", Role: "user", Metadata: model.MessageMetadata{UserVisible: &hidden}},
+			{UUID: "notice", Role: "assistant", Metadata: model.MessageMetadata{AgentVisible: &hidden}},
+		},
+	}
+
+	tests := []struct {
+		visibleTo string
+		want      []string
+	}{
+		{"", []string{"typed", "injected", "notice"}},
+		{VisibleToUser, []string{"typed", "notice"}},
+		{VisibleToAgent, []string{"typed", "injected"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.visibleTo, func(t *testing.T) {
+			var got []string
+			for _, msg := range Content(session, Options{VisibleTo: tt.visibleTo}).Messages {
+				got = append(got, msg.UUID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("messages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentActiveBranch(t *testing.T) {
+	session := model.Session{
+		Messages: []model.Message{{UUID: "u1"}, {UUID: "a1"}, {UUID: "u2"}, {UUID: "a2"}, {UUID: "u3"}, {UUID: "a3"}},
+		Branches: []model.Branch{
+			{LeafUUID: "a3", Active: true, MessageUUIDs: []string{"u1", "a1", "u3", "a3"}},
+			{LeafUUID: "a2", ForkUUID: "a1", MessageUUIDs: []string{"u1", "a1", "u2", "a2"}},
+		},
+	}
+
+	tests := []struct {
+		branch string
+		want   []string
+	}{
+		{"", []string{"u1", "a1", "u2", "a2", "u3", "a3"}},
+		{BranchAll, []string{"u1", "a1", "u2", "a2", "u3", "a3"}},
+		{BranchActive, []string{"u1", "a1", "u3", "a3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			var got []string
+			for _, msg := range Content(session, Options{Branch: tt.branch}).Messages {
+				got = append(got, msg.UUID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("messages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
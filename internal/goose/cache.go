@@ -0,0 +1,52 @@
+package goose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/block/braindump/model"
+)
+
+// cacheNamespace holds cached Goose messages. Bump the version whenever
+// message parsing changes.
+const cacheNamespace = "goose-v8"
+
+// cacheEntry holds a session's messages, and the problems found reading
+// them, as of its updated_at value
+type cacheEntry struct {
+	UpdatedAt   string             `json:"updated_at"`
+	Messages    []model.Message    `json:"messages"`
+	Diagnostics []model.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// cachedMessages returns the messages for a session and the problems found
+// reading them, serving both from the cache when the session's updated_at is
+// unchanged since they were cached
+func (r *Reader) cachedMessages(db querier, schema *schema, dbPath, sessionID, updatedAt string) ([]model.Message, []model.Diagnostic, error) {
+	if r.cache == nil || updatedAt == "" {
+		return r.readMessages(db, schema, sessionID)
+	}
+
+	if abs, err := filepath.Abs(dbPath); err == nil {
+		dbPath = abs
+	}
+	key := dbPath + "\x00" + sessionID
+
+	var entry cacheEntry
+	if r.cache.Load(cacheNamespace, key, &entry) && entry.UpdatedAt == updatedAt {
+		return entry.Messages, entry.Diagnostics, nil
+	}
+
+	messages, problems, err := r.readMessages(db, schema, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = r.cache.Store(cacheNamespace, key, cacheEntry{UpdatedAt: updatedAt, Messages: messages, Diagnostics: problems})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to cache session %s: %v\n", sessionID, err)
+	}
+
+	return messages, problems, nil
+}
@@ -0,0 +1,151 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// DefaultBusyTimeout is how long a read waits for Goose to release a lock on the database
+const DefaultBusyTimeout = 5 * time.Second
+
+// ErrLocked is wrapped by errors caused by another process (usually a running
+// Goose) holding a lock on the database for longer than the busy timeout
+var ErrLocked = errors.New("database is locked by another process")
+
+// lockedError wraps an error caused by a lock with ErrLocked and tells the
+// user how to get past the lock
+type lockedError struct {
+	err error
+}
+
+func (e *lockedError) Error() string {
+	return ErrLocked.Error() + ": " + e.err.Error()
+}
+
+func (e *lockedError) Unwrap() []error {
+	return []error{ErrLocked, e.err}
+}
+
+// Hint suggests the flags that avoid waiting on a running Goose
+func (e *lockedError) Hint() string {
+	return "retry, raise --goose-busy-timeout or use --goose-snapshot"
+}
+
+// openDB opens a sessions database read-only, so braindump can never modify
+// it. With snapshot set, the database is first
+// copied to a temporary file with the SQLite backup API, which sees a single
+// consistent state even while Goose is writing, and the copy is read instead.
+// The returned function closes the database and removes any snapshot.
+func openDB(ctx context.Context, dbPath string, busyTimeout time.Duration, snapshot bool) (*sql.DB, func(), error) {
+	db, err := sql.Open("sqlite", readOnlyDSN(dbPath, busyTimeout, false))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if !snapshot {
+		return db, func() { db.Close() }, nil
+	}
+	defer db.Close()
+
+	copyPath, err := backup(ctx, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	remove := func() { os.Remove(copyPath) }
+
+	// Nothing else writes to the copy, so SQLite can skip locking it entirely
+	snap, err := sql.Open("sqlite", readOnlyDSN(copyPath, busyTimeout, true))
+	if err != nil {
+		remove()
+		return nil, nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	return snap, func() {
+		snap.Close()
+		remove()
+	}, nil
+}
+
+// readOnlyDSN returns the URI opening path read-only with a busy timeout.
+// Immutable databases are opened without any locking or change detection.
+func readOnlyDSN(path string, busyTimeout time.Duration, immutable bool) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	query := url.Values{}
+	query.Set("mode", "ro")
+	if immutable {
+		query.Set("immutable", "1")
+	}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(path), RawQuery: query.Encode()}
+	return uri.String()
+}
+
+// backup copies the database to a temporary file with the SQLite online
+// backup API and returns the file's path
+func backup(ctx context.Context, db *sql.DB) (string, error) {
+	file, err := os.CreateTemp("", "braindump-goose-*.db")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	path := file.Name()
+	file.Close()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		backuper, ok := driverConn.(interface {
+			NewBackup(dstURI string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return errors.New("the SQLite driver does not support backups")
+		}
+
+		b, err := backuper.NewBackup(path)
+		if err != nil {
+			return err
+		}
+		// Copying every page in one step holds the read lock throughout, so
+		// the copy cannot mix pages from before and after a write
+		if _, err := b.Step(-1); err != nil {
+			_ = b.Finish()
+			return err
+		}
+		return b.Finish()
+	})
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to snapshot database: %w", err)
+	}
+
+	return path, nil
+}
+
+// isLocked reports whether err was caused by a lock held by another connection
+func isLocked(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	// Extended result codes keep the primary code in the low byte
+	switch sqliteErr.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
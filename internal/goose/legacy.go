@@ -0,0 +1,282 @@
+package goose
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/block/braindump/internal/timestamp"
+	"github.com/block/braindump/model"
+)
+
+// legacyCacheNamespace holds sessions parsed from legacy JSONL files. Bump the
+// version whenever legacy or content parsing changes.
+const legacyCacheNamespace = "goose-legacy-v7"
+
+// legacyCacheEntry holds a legacy session, and the problems found reading
+// it, as of the file's size and modification time
+type legacyCacheEntry struct {
+	ModTime     time.Time          `json:"mod_time"`
+	Size        int64              `json:"size"`
+	Session     model.Session      `json:"session"`
+	Diagnostics []model.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// legacyMetadata is the first line of a legacy session file
+type legacyMetadata struct {
+	WorkingDir              string          `json:"working_dir"`
+	Description             string          `json:"description"`
+	ScheduleID              string          `json:"schedule_id"`
+	InputTokens             *int64          `json:"input_tokens"`
+	OutputTokens            *int64          `json:"output_tokens"`
+	TotalTokens             *int64          `json:"total_tokens"`
+	AccumulatedInputTokens  *int64          `json:"accumulated_input_tokens"`
+	AccumulatedOutputTokens *int64          `json:"accumulated_output_tokens"`
+	AccumulatedTotalTokens  *int64          `json:"accumulated_total_tokens"`
+	ExtensionData           json.RawMessage `json:"extension_data"`
+}
+
+// legacyMessage is a message line of a legacy session file
+type legacyMessage struct {
+	ID       string         `json:"id"`
+	Role     string         `json:"role"`
+	Created  int64          `json:"created"` // unix seconds
+	Content  []any          `json:"content"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// legacyFiles returns the legacy JSONL session files in dir, most recently
+// modified first, or none if dir is ""
+func legacyFiles(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	modTimes := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return modTimes[paths[i]].After(modTimes[paths[j]])
+	})
+	return paths, nil
+}
+
+// readLegacySessions yields sessions from the JSONL files older Goose versions
+// wrote before the sessions database, most recently modified first, parsing
+// each file only when it is yielded. Sessions already in seen (read from the
+// database, which Goose migrates legacy files into) are skipped.
+// It reports whether yield asked to stop.
+func (r *Reader) readLegacySessions(dir string, seen map[string]bool, yield func(model.Session, error) bool) (bool, error) {
+	files, err := legacyFiles(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to list legacy sessions: %w", err)
+	}
+
+	for _, path := range files {
+		if seen[legacySessionID(path)] {
+			continue
+		}
+
+		session, problems, err := r.cachedLegacySession(path)
+		if err != nil {
+			r.report(model.Diagnostic{File: path, Kind: model.DiagnosticReadFailed, Message: err.Error()})
+			continue
+		}
+		for _, d := range problems {
+			r.report(d)
+		}
+		if !yield(session, nil) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// legacySessionID returns the session ID of a legacy file: its name without the extension
+func legacySessionID(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".jsonl")
+}
+
+// cachedLegacySession parses a legacy session file and returns the problems
+// found in it, serving both from the cache when the file is unchanged since
+// they were cached
+func (r *Reader) cachedLegacySession(path string) (model.Session, []model.Diagnostic, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return model.Session{}, nil, err
+	}
+	if r.cache == nil {
+		return readLegacySession(path, info)
+	}
+
+	key := path
+	if abs, err := filepath.Abs(path); err == nil {
+		key = abs
+	}
+
+	var entry legacyCacheEntry
+	if r.cache.Load(legacyCacheNamespace, key, &entry) && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		return entry.Session, entry.Diagnostics, nil
+	}
+
+	session, problems, err := readLegacySession(path, info)
+	if err != nil {
+		return session, nil, err
+	}
+
+	err = r.cache.Store(legacyCacheNamespace, key, legacyCacheEntry{ModTime: info.ModTime(), Size: info.Size(), Session: session, Diagnostics: problems})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to cache legacy session %s: %v\n", path, err)
+	}
+
+	return session, problems, nil
+}
+
+// readLegacySession parses a legacy session file: an optional metadata line
+// followed by one message per line. Malformed lines are skipped and returned
+// as diagnostics.
+func readLegacySession(path string, info os.FileInfo) (model.Session, []model.Diagnostic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return model.Session{}, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	session := model.Session{
+		AgentType: "goose",
+		SessionID: legacySessionID(path),
+	}
+
+	var problems []model.Diagnostic
+	problem := func(line int, field, message string) {
+		problems = append(problems, model.Diagnostic{
+			File:      path,
+			Line:      line,
+			SessionID: session.SessionID,
+			Field:     field,
+			Kind:      model.DiagnosticMalformedRecord,
+			Message:   message,
+		})
+	}
+
+	reader := bufio.NewReader(file)
+	first := true
+
+	for number := 1; ; number++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return session, nil, fmt.Errorf("error reading file: %w", err)
+		}
+
+		line = bytes.TrimSpace(line)
+		switch {
+		case len(line) == 0:
+		case !json.Valid(line):
+			problem(number, "", "line is not valid JSON")
+			first = false
+		default:
+			msg, msgErr := parseLegacyMessage(line)
+			switch {
+			case msgErr == nil:
+				session.Messages = append(session.Messages, msg)
+			case first:
+				if field, metaErr := parseLegacyMetadata(line, &session.Metadata); metaErr != nil {
+					problem(number, field, metaErr.Error())
+				}
+			default:
+				problem(number, "", msgErr.Error())
+			}
+			first = false
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	// Timestamps come from the messages, falling back to the file's modification time
+	for _, msg := range session.Messages {
+		if msg.Timestamp.IsZero() {
+			continue
+		}
+		if session.CreatedAt.IsZero() || msg.Timestamp.Before(session.CreatedAt) {
+			session.CreatedAt = msg.Timestamp
+		}
+		if session.UpdatedAt.IsZero() || msg.Timestamp.After(session.UpdatedAt) {
+			session.UpdatedAt = msg.Timestamp
+		}
+	}
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt, session.UpdatedAt = info.ModTime(), info.ModTime()
+	}
+
+	return session, problems, nil
+}
+
+// parseLegacyMetadata parses the metadata line of a legacy session file.
+// On error it returns the field that could not be decoded, if known.
+func parseLegacyMetadata(line []byte, metadata *model.SessionMetadata) (string, error) {
+	var raw legacyMetadata
+	if err := json.Unmarshal(line, &raw); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return typeErr.Field, err
+		}
+		return "", err
+	}
+
+	metadata.WorkingDir = raw.WorkingDir
+	metadata.ScheduleID = raw.ScheduleID
+	metadata.Tokens = tokenUsage(raw.InputTokens, raw.OutputTokens, raw.TotalTokens)
+	metadata.AccumulatedTokens = tokenUsage(raw.AccumulatedInputTokens, raw.AccumulatedOutputTokens, raw.AccumulatedTotalTokens)
+	if raw.Description != "" {
+		metadata.Extra = map[string]string{"description": raw.Description}
+	}
+	if len(raw.ExtensionData) > 0 {
+		if err := parseExtensionData(raw.ExtensionData, metadata); err != nil {
+			return "extension_data", err
+		}
+	}
+	return "", nil
+}
+
+// parseLegacyMessage parses a message line. It returns an error for lines
+// that are not messages: the metadata line, or lines that do not decode.
+func parseLegacyMessage(line []byte) (model.Message, error) {
+	var raw legacyMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return model.Message{}, err
+	}
+	if raw.Role == "" {
+		return model.Message{}, errors.New("line is not a message: it has no role")
+	}
+
+	msg := model.Message{
+		UUID:    raw.ID,
+		Role:    raw.Role,
+		Content: parseContentList(raw.Content),
+	}
+	if raw.Created != 0 {
+		msg.Timestamp = timestamp.Unix(raw.Created)
+	}
+
+	parseMessageMetadata(raw.Metadata, &msg.Metadata)
+
+	return msg, nil
+}
@@ -0,0 +1,111 @@
+package goose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLegacySessions(t *testing.T) {
+	dir := t.TempDir()
+	writeTestDB(t, filepath.Join(dir, dbFileName), testSchema,
+		`INSERT INTO sessions (id, name, created_at, updated_at)
+		 VALUES ('20250101_1', 'Migrated', '2025-01-01T10:00:00Z', '2025-01-01T10:05:00Z')`,
+	)
+
+	files := map[string]string{
+		// Migrated into the database, so skipped
+		"20250101_1.jsonl": `{"working_dir":"/old","description":"Duplicate"}
+{"id":"m1","role":"user","created":1735725600,"content":[{"type":"text","text":"dup"}]}
+`,
+		"20240601_3.jsonl": `{"working_dir":"/src/app","description":"Add login page","schedule_id":"daily","message_count":2,"total_tokens":null,"accumulated_total_tokens":4200,"extension_data":{"enabled_extensions.v0":{"extensions":[{"type":"builtin","name":"developer"}]}}}
+{"id":"m1","role":"user","created":1717236000,"content":[{"type":"text","text":"Add a login page"}],"metadata":{"userVisible":true}}
+{not json}
+{"id":"m2","role":"assistant","created":1717236060,"content":[{"type":"toolRequest","id":"t1","toolCall":{"status":"success","value":{"name":"developer__shell","arguments":{"command":"ls"}}}}]}`,
+		// No metadata line
+		"20240501_2.jsonl": `{"id":"m1","role":"user","created":1714557600,"content":[{"type":"text","text":"hello"}]}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Legacy sessions are ordered by when their files were last written
+	for name, modTime := range map[string]int64{"20240601_3.jsonl": 1717236060, "20240501_2.jsonl": 1714557600} {
+		if err := os.Chtimes(filepath.Join(dir, name), time.Unix(modTime, 0), time.Unix(modTime, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sessions := readAll(t, dir)
+
+	var ids []string
+	for _, session := range sessions {
+		ids = append(ids, session.SessionID)
+	}
+	if len(ids) != 3 || ids[0] != "20250101_1" || ids[1] != "20240601_3" || ids[2] != "20240501_2" {
+		t.Fatalf("sessions = %v, want database session then legacy sessions newest first", ids)
+	}
+
+	legacy := sessions[1]
+	if legacy.AgentType != "goose" || legacy.Metadata.WorkingDir != "/src/app" || legacy.Metadata.Extra["description"] != "Add login page" {
+		t.Errorf("unexpected metadata: %+v", legacy.Metadata)
+	}
+	if legacy.Metadata.ScheduleID != "daily" || legacy.Metadata.Tokens != nil ||
+		legacy.Metadata.AccumulatedTokens == nil || legacy.Metadata.AccumulatedTokens.TotalTokens != 4200 ||
+		len(legacy.Metadata.Extensions) != 1 || legacy.Metadata.Extensions[0].Name != "developer" {
+		t.Errorf("unexpected schedule, token or extension metadata: %+v", legacy.Metadata)
+	}
+	if len(legacy.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(legacy.Messages))
+	}
+	if tool := legacy.Messages[1].Content[0]; tool.Type != "tool_use" || tool.ToolName != "developer__shell" {
+		t.Errorf("unexpected tool block: %+v", tool)
+	}
+
+	wantCreated := time.Unix(1717236000, 0).UTC()
+	if !legacy.CreatedAt.Equal(wantCreated) || !legacy.UpdatedAt.Equal(wantCreated.Add(time.Minute)) {
+		t.Errorf("timestamps = %v - %v, want %v - %v", legacy.CreatedAt, legacy.UpdatedAt, wantCreated, wantCreated.Add(time.Minute))
+	}
+}
+
+func TestLegacySessionsWithoutDatabase(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"id":"m1","role":"user","created":1714557600,"content":[{"type":"text","text":"hello"}]}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "old.jsonl"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(Options{DBPaths: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reader.Detect() {
+		t.Error("Detect should find legacy session files")
+	}
+
+	sessions := readAll(t, dir)
+	if len(sessions) != 1 || sessions[0].SessionID != "old" {
+		t.Errorf("sessions = %+v, want the legacy session", sessions)
+	}
+}
+
+func TestLegacySessionsCustomDatabase(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "backup.db")
+	writeTestDB(t, dbPath, testSchema,
+		`INSERT INTO sessions (id, created_at, updated_at) VALUES ('s1', '2025-01-01T10:00:00Z', '2025-01-01T10:05:00Z')`,
+	)
+	// Not in a Goose sessions directory, so not a legacy session
+	content := `{"id":"m1","role":"user","created":1714557600,"content":[{"type":"text","text":"hello"}]}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "events.jsonl"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := readAll(t, dbPath)
+	if len(sessions) != 1 || sessions[0].SessionID != "s1" {
+		t.Errorf("sessions = %+v, want only the database session", sessions)
+	}
+}
@@ -0,0 +1,131 @@
+package goose
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/block/braindump/model"
+)
+
+// Extension states Goose keeps in extension_data, keyed by "name.version"
+const (
+	enabledExtensionsState = "enabled_extensions"
+	todoState              = "todo"
+)
+
+// Message visibility flags Goose records in message metadata
+const (
+	userVisibleKey  = "userVisible"
+	agentVisibleKey = "agentVisible"
+)
+
+// parseMessageMetadata sets the visibility flags from a message's metadata
+// and keeps every other value, whatever its type, in Extra
+func parseMessageMetadata(raw map[string]any, metadata *model.MessageMetadata) {
+	for key, value := range raw {
+		switch flag, isBool := value.(bool); {
+		case key == userVisibleKey && isBool:
+			metadata.UserVisible = &flag
+		case key == agentVisibleKey && isBool:
+			metadata.AgentVisible = &flag
+		default:
+			if metadata.Extra == nil {
+				metadata.Extra = make(map[string]any)
+			}
+			metadata.Extra[key] = value
+		}
+	}
+}
+
+// tokenUsage builds token usage from nullable counts, or returns nil if none were recorded
+func tokenUsage(input, output, total *int64) *model.TokenUsage {
+	if input == nil && output == nil && total == nil {
+		return nil
+	}
+
+	var usage model.TokenUsage
+	if input != nil {
+		usage.InputTokens = int(*input)
+	}
+	if output != nil {
+		usage.OutputTokens = int(*output)
+	}
+	if total != nil {
+		usage.TotalTokens = int(*total)
+	}
+	return &usage
+}
+
+// parseExtensionData sets the enabled extensions, todo list and any other
+// extension state from a session's extension_data. It returns an error if the
+// data is not a JSON object.
+func parseExtensionData(data []byte, metadata *model.SessionMetadata) error {
+	var states map[string]json.RawMessage
+	if err := json.Unmarshal(data, &states); err != nil {
+		return err
+	}
+
+	for key, state := range states {
+		name, _, _ := strings.Cut(key, ".")
+
+		switch name {
+		case enabledExtensionsState:
+			var enabled struct {
+				Extensions []model.Extension `json:"extensions"`
+			}
+			if err := json.Unmarshal(state, &enabled); err == nil {
+				metadata.Extensions = enabled.Extensions
+				continue
+			}
+
+		case todoState:
+			var todo struct {
+				Content string `json:"content"`
+			}
+			if err := json.Unmarshal(state, &todo); err == nil {
+				metadata.Todo = todo.Content
+				continue
+			}
+		}
+
+		// Keep state braindump does not understand as is
+		var value any
+		if err := json.Unmarshal(state, &value); err != nil {
+			continue
+		}
+		if metadata.ExtensionState == nil {
+			metadata.ExtensionState = make(map[string]any)
+		}
+		metadata.ExtensionState[key] = value
+	}
+	return nil
+}
+
+// parseRecipe parses the recipe a session was started from and the values
+// the user supplied for its parameters, passing each column that cannot be
+// decoded to problem. It returns nil if there is no recipe.
+func parseRecipe(recipeJSON, valuesJSON []byte, problem func(field string, err error)) *model.Recipe {
+	var raw struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Version     string `json:"version"`
+	}
+	if len(recipeJSON) > 0 {
+		if err := json.Unmarshal(recipeJSON, &raw); err != nil {
+			problem("recipe_json", err)
+			return nil
+		}
+	}
+
+	recipe := model.Recipe{Title: raw.Title, Description: raw.Description, Version: raw.Version}
+	if len(valuesJSON) > 0 {
+		if err := json.Unmarshal(valuesJSON, &recipe.Parameters); err != nil {
+			problem("user_recipe_values_json", err)
+		}
+	}
+
+	if recipe.Title == "" && recipe.Description == "" && recipe.Version == "" && len(recipe.Parameters) == 0 {
+		return nil
+	}
+	return &recipe
+}
@@ -2,8 +2,10 @@ package goose
 
 import (
 	"encoding/json"
+	"slices"
+	"strings"
 
-	"github.com/block/braindump/internal/model"
+	"github.com/block/braindump/model"
 )
 
 // parseContent parses Goose content JSON into content blocks
@@ -26,19 +28,7 @@ func parseContent(contentJSON string) []model.ContentBlock {
 
 	case []any:
 		// Array of content blocks
-		for _, item := range v {
-			if block, isBlock := item.(map[string]any); isBlock {
-				contentBlock := parseContentBlock(block)
-				if contentBlock != nil {
-					blocks = append(blocks, *contentBlock)
-				}
-			} else if str, isStr := item.(string); isStr {
-				blocks = append(blocks, model.ContentBlock{
-					Type: "text",
-					Text: str,
-				})
-			}
-		}
+		blocks = parseContentList(v)
 
 	case map[string]any:
 		// Single content block
@@ -51,6 +41,75 @@ func parseContent(contentJSON string) []model.ContentBlock {
 	return blocks
 }
 
+// noticeTypes maps Goose notice content types to unified block types
+var noticeTypes = map[string]string{
+	"contextLengthExceeded":  "context_length_exceeded",
+	"summarizationRequested": "summarization_requested",
+}
+
+// parseContentList parses a list of content blocks, where plain strings are text
+func parseContentList(items []any) []model.ContentBlock {
+	var blocks []model.ContentBlock
+
+	for _, item := range items {
+		if block, isBlock := item.(map[string]any); isBlock {
+			contentBlock := parseContentBlock(block)
+			if contentBlock != nil {
+				contentBlock.Audience = parseAudience(block)
+				blocks = append(blocks, *contentBlock)
+			}
+		} else if str, isStr := item.(string); isStr {
+			blocks = append(blocks, model.ContentBlock{
+				Type: "text",
+				Text: str,
+			})
+		}
+	}
+
+	return blocks
+}
+
+// parseAudience returns the audience of an MCP content item's annotations
+func parseAudience(block map[string]any) []string {
+	annotations, _ := block["annotations"].(map[string]any)
+	items, _ := annotations["audience"].([]any)
+
+	var audience []string
+	for _, item := range items {
+		if role, ok := item.(string); ok {
+			audience = append(audience, role)
+		}
+	}
+	return audience
+}
+
+// forAssistant reports whether a tool result part was sent to the model
+func forAssistant(block model.ContentBlock) bool {
+	return len(block.Audience) == 0 || slices.Contains(block.Audience, "assistant")
+}
+
+// joinText joins the text of content blocks, including text resources, with
+// newlines. Goose tools usually return their output twice, once for the
+// assistant and once for the user, so only the parts the assistant saw are
+// joined unless there are none.
+func joinText(blocks []model.ContentBlock) string {
+	if slices.ContainsFunc(blocks, forAssistant) {
+		blocks = slices.DeleteFunc(slices.Clone(blocks), func(block model.ContentBlock) bool {
+			return !forAssistant(block)
+		})
+	}
+
+	var texts []string
+	for _, block := range blocks {
+		if block.Text != "" {
+			texts = append(texts, block.Text)
+		} else if block.Type == "document" && block.SourceType == "text" && block.Data != "" {
+			texts = append(texts, block.Data)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
 // parseContentBlock parses a single content block
 func parseContentBlock(block map[string]any) *model.ContentBlock {
 	blockType, _ := block["type"].(string)
@@ -63,6 +122,17 @@ func parseContentBlock(block map[string]any) *model.ContentBlock {
 			Text: text,
 		}
 
+	case "image":
+		// Goose stores images inline as base64
+		data, _ := block["data"].(string)
+		mimeType, _ := block["mimeType"].(string)
+		return &model.ContentBlock{
+			Type:       "image",
+			Data:       data,
+			MediaType:  mimeType,
+			SourceType: "base64",
+		}
+
 	case "tool_use":
 		toolName, _ := block["name"].(string)
 		toolUseID, _ := block["id"].(string)
@@ -75,19 +145,60 @@ func parseContentBlock(block map[string]any) *model.ContentBlock {
 			ToolInput: toolInput,
 		}
 
+	case "resource":
+		return parseResource(block)
+
+	case "thinking":
+		thinking, _ := block["thinking"].(string)
+		signature, _ := block["signature"].(string)
+		return &model.ContentBlock{
+			Type:      "thinking",
+			Thinking:  thinking,
+			Signature: signature,
+		}
+
+	case "redactedThinking":
+		data, _ := block["data"].(string)
+		return &model.ContentBlock{
+			Type: "redacted_thinking",
+			Data: data,
+		}
+
+	case "toolRequest", "frontendToolRequest":
+		return parseToolRequest(block)
+
+	case "toolResponse":
+		return parseToolResponse(block)
+
+	case "contextLengthExceeded", "summarizationRequested":
+		// Notices shown to the user when the conversation outgrows the context window
+		msg, _ := block["msg"].(string)
+		return &model.ContentBlock{
+			Type: noticeTypes[blockType],
+			Text: msg,
+		}
+
 	case "tool_result":
 		toolUseID, _ := block["tool_use_id"].(string)
+		isError, _ := block["is_error"].(bool)
 
-		// Content can be string or nested
+		// Content can be string or a list of content blocks
 		var toolContent string
-		if contentStr, ok := block["content"].(string); ok {
-			toolContent = contentStr
+		var parts []model.ContentBlock
+		switch content := block["content"].(type) {
+		case string:
+			toolContent = content
+		case []any:
+			parts = parseContentList(content)
+			toolContent = joinText(parts)
 		}
 
 		return &model.ContentBlock{
 			Type:        "tool_result",
 			ToolUseID:   toolUseID,
 			ToolContent: toolContent,
+			Parts:       parts,
+			IsError:     isError,
 		}
 
 	default:
@@ -102,3 +213,80 @@ func parseContentBlock(block map[string]any) *model.ContentBlock {
 
 	return nil
 }
+
+// parseToolRequest parses a Goose tool call. The call is wrapped in a result:
+// {"status": "success", "value": {"name", "arguments"}} or {"status": "error", "error"}
+// when the model produced a call Goose could not parse.
+func parseToolRequest(block map[string]any) *model.ContentBlock {
+	toolUseID, _ := block["id"].(string)
+	contentBlock := &model.ContentBlock{
+		Type:      "tool_use",
+		ToolUseID: toolUseID,
+	}
+
+	toolCall, _ := block["toolCall"].(map[string]any)
+	if value, ok := toolCall["value"].(map[string]any); ok {
+		contentBlock.ToolName, _ = value["name"].(string)
+		contentBlock.ToolInput, _ = value["arguments"].(map[string]any)
+	}
+	if errMsg, ok := toolCall["error"].(string); ok {
+		contentBlock.Text = errMsg
+	}
+
+	return contentBlock
+}
+
+// parseToolResponse parses a Goose tool result. The result is wrapped like a
+// tool call, with a value that is either a list of content items or, in newer
+// versions, an MCP call result {"content": [...], "isError": bool}.
+func parseToolResponse(block map[string]any) *model.ContentBlock {
+	toolUseID, _ := block["id"].(string)
+	contentBlock := &model.ContentBlock{
+		Type:      "tool_result",
+		ToolUseID: toolUseID,
+	}
+
+	toolResult, _ := block["toolResult"].(map[string]any)
+
+	if status, _ := toolResult["status"].(string); status == "error" {
+		contentBlock.IsError = true
+		contentBlock.ToolContent, _ = toolResult["error"].(string)
+		return contentBlock
+	}
+
+	var items []any
+	switch value := toolResult["value"].(type) {
+	case []any:
+		items = value
+	case map[string]any:
+		items, _ = value["content"].([]any)
+		contentBlock.IsError, _ = value["isError"].(bool)
+	}
+
+	contentBlock.Parts = parseContentList(items)
+	contentBlock.ToolContent = joinText(contentBlock.Parts)
+	return contentBlock
+}
+
+// parseResource parses an embedded MCP resource as a document
+func parseResource(block map[string]any) *model.ContentBlock {
+	resource, _ := block["resource"].(map[string]any)
+	uri, _ := resource["uri"].(string)
+	mimeType, _ := resource["mimeType"].(string)
+
+	contentBlock := &model.ContentBlock{
+		Type:      "document",
+		Title:     uri,
+		MediaType: mimeType,
+	}
+
+	if text, ok := resource["text"].(string); ok {
+		contentBlock.SourceType = "text"
+		contentBlock.Data = text
+	} else if blob, ok := resource["blob"].(string); ok {
+		contentBlock.SourceType = "base64"
+		contentBlock.Data = blob
+	}
+
+	return contentBlock
+}
@@ -1,9 +1,10 @@
 package goose
 
 import (
+	"reflect"
 	"testing"
 
-	"github.com/block/braindump/internal/model"
+	"github.com/block/braindump/model"
 )
 
 func TestParseContent(t *testing.T) {
@@ -97,6 +98,42 @@ func TestParseContentBlock(t *testing.T) {
 				ToolContent: "file1.txt\nfile2.txt",
 			},
 		},
+		{
+			name: "tool result block with content list",
+			block: map[string]any{
+				"type":        "tool_result",
+				"tool_use_id": "tool-789",
+				"is_error":    true,
+				"content": []any{
+					map[string]any{"type": "text", "text": "permission denied"},
+					map[string]any{"type": "image", "data": "iVBORw0KGgo=", "mimeType": "image/png"},
+				},
+			},
+			expected: &model.ContentBlock{
+				Type:        "tool_result",
+				ToolUseID:   "tool-789",
+				ToolContent: "permission denied",
+				Parts: []model.ContentBlock{
+					{Type: "text", Text: "permission denied"},
+					{Type: "image", Data: "iVBORw0KGgo=", MediaType: "image/png", SourceType: "base64"},
+				},
+				IsError: true,
+			},
+		},
+		{
+			name: "image block",
+			block: map[string]any{
+				"type":     "image",
+				"data":     "iVBORw0KGgo=",
+				"mimeType": "image/png",
+			},
+			expected: &model.ContentBlock{
+				Type:       "image",
+				Data:       "iVBORw0KGgo=",
+				MediaType:  "image/png",
+				SourceType: "base64",
+			},
+		},
 		{
 			name: "unknown type with text",
 			block: map[string]any{
@@ -143,6 +180,156 @@ func TestParseContentBlock(t *testing.T) {
 			if result.ToolName != tt.expected.ToolName {
 				t.Errorf("ToolName: got %q, want %q", result.ToolName, tt.expected.ToolName)
 			}
+
+			if result.ToolContent != tt.expected.ToolContent {
+				t.Errorf("ToolContent: got %q, want %q", result.ToolContent, tt.expected.ToolContent)
+			}
+
+			if result.IsError != tt.expected.IsError || len(result.Parts) != len(tt.expected.Parts) {
+				t.Errorf("IsError/Parts: got %v %d, want %v %d", result.IsError, len(result.Parts), tt.expected.IsError, len(tt.expected.Parts))
+			}
+
+			if result.Data != tt.expected.Data || result.MediaType != tt.expected.MediaType || result.SourceType != tt.expected.SourceType {
+				t.Errorf("Media: got %q %q %q, want %q %q %q", result.Data, result.MediaType, result.SourceType,
+					tt.expected.Data, tt.expected.MediaType, tt.expected.SourceType)
+			}
+		})
+	}
+}
+
+// TestParseContentFixtures parses content_json values as written by Goose
+func TestParseContentFixtures(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []model.ContentBlock
+	}{
+		{
+			name: "text with tool request",
+			json: `[
+				{"type":"text","text":"I'll list the files."},
+				{"type":"toolRequest","id":"toolu_01A","toolCall":{"status":"success","value":{"name":"developer__shell","arguments":{"command":"ls -la"}}}}
+			]`,
+			expected: []model.ContentBlock{
+				{Type: "text", Text: "I'll list the files."},
+				{Type: "tool_use", ToolUseID: "toolu_01A", ToolName: "developer__shell", ToolInput: map[string]any{"command": "ls -la"}},
+			},
+		},
+		{
+			name: "tool request that failed to parse",
+			json: `[{"type":"toolRequest","id":"toolu_01B","toolCall":{"status":"error","error":"Could not interpret tool use parameters"}}]`,
+			expected: []model.ContentBlock{
+				{Type: "tool_use", ToolUseID: "toolu_01B", Text: "Could not interpret tool use parameters"},
+			},
+		},
+		{
+			name: "frontend tool request",
+			json: `[{"type":"frontendToolRequest","id":"toolu_01C","toolCall":{"status":"success","value":{"name":"browser__click","arguments":{"selector":"#save"}}}}]`,
+			expected: []model.ContentBlock{
+				{Type: "tool_use", ToolUseID: "toolu_01C", ToolName: "browser__click", ToolInput: map[string]any{"selector": "#save"}},
+			},
+		},
+		{
+			name: "tool response with content list",
+			json: `[{"type":"toolResponse","id":"toolu_01A","toolResult":{"status":"success","value":[
+				{"type":"text","text":"total 8\nmain.go","annotations":{"audience":["assistant"]}},
+				{"type":"text","text":"total 8\nmain.go","annotations":{"audience":["user"],"priority":0.0}}
+			]}}]`,
+			expected: []model.ContentBlock{
+				{
+					Type:        "tool_result",
+					ToolUseID:   "toolu_01A",
+					ToolContent: "total 8\nmain.go",
+					Parts: []model.ContentBlock{
+						{Type: "text", Text: "total 8\nmain.go", Audience: []string{"assistant"}},
+						{Type: "text", Text: "total 8\nmain.go", Audience: []string{"user"}},
+					},
+				},
+			},
+		},
+		{
+			name: "tool response for the user only",
+			json: `[{"type":"toolResponse","id":"toolu_01E","toolResult":{"status":"success","value":[
+				{"type":"text","text":"Opened the browser","annotations":{"audience":["user"]}}
+			]}}]`,
+			expected: []model.ContentBlock{
+				{
+					Type:        "tool_result",
+					ToolUseID:   "toolu_01E",
+					ToolContent: "Opened the browser",
+					Parts:       []model.ContentBlock{{Type: "text", Text: "Opened the browser", Audience: []string{"user"}}},
+				},
+			},
+		},
+		{
+			name: "tool response with MCP call result",
+			json: `[{"type":"toolResponse","id":"toolu_01D","toolResult":{"status":"success","value":{"content":[{"type":"text","text":"No such file"}],"isError":true}}}]`,
+			expected: []model.ContentBlock{
+				{
+					Type:        "tool_result",
+					ToolUseID:   "toolu_01D",
+					ToolContent: "No such file",
+					Parts:       []model.ContentBlock{{Type: "text", Text: "No such file"}},
+					IsError:     true,
+				},
+			},
+		},
+		{
+			name: "tool response error",
+			json: `[{"type":"toolResponse","id":"toolu_01E","toolResult":{"status":"error","error":"Execution failed: command timed out"}}]`,
+			expected: []model.ContentBlock{
+				{Type: "tool_result", ToolUseID: "toolu_01E", ToolContent: "Execution failed: command timed out", IsError: true},
+			},
+		},
+		{
+			name: "tool response with image and resource",
+			json: `[{"type":"toolResponse","id":"toolu_01F","toolResult":{"status":"success","value":[
+				{"type":"image","data":"iVBORw0KGgo=","mimeType":"image/png"},
+				{"type":"resource","resource":{"uri":"file:///src/main.go","mimeType":"text/x-go","text":"package main"}}
+			]}}]`,
+			expected: []model.ContentBlock{
+				{
+					Type:        "tool_result",
+					ToolUseID:   "toolu_01F",
+					ToolContent: "package main",
+					Parts: []model.ContentBlock{
+						{Type: "image", Data: "iVBORw0KGgo=", MediaType: "image/png", SourceType: "base64"},
+						{Type: "document", Title: "file:///src/main.go", MediaType: "text/x-go", SourceType: "text", Data: "package main"},
+					},
+				},
+			},
+		},
+		{
+			name: "thinking",
+			json: `[
+				{"type":"thinking","thinking":"The test imports the wrong package.","signature":"EqMBCkgIAR..."},
+				{"type":"redactedThinking","data":"EmwKAhgBEgy..."}
+			]`,
+			expected: []model.ContentBlock{
+				{Type: "thinking", Thinking: "The test imports the wrong package.", Signature: "EqMBCkgIAR..."},
+				{Type: "redacted_thinking", Data: "EmwKAhgBEgy..."},
+			},
+		},
+		{
+			name: "context notices",
+			json: `[
+				{"type":"contextLengthExceeded","msg":"The context length of the model has been exceeded."},
+				{"type":"summarizationRequested","msg":"Summarizing the conversation to continue."}
+			]`,
+			expected: []model.ContentBlock{
+				{Type: "context_length_exceeded", Text: "The context length of the model has been exceeded."},
+				{Type: "summarization_requested", Text: "Summarizing the conversation to continue."},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseContent(tt.json)
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseContent:\ngot  %+v\nwant %+v", result, tt.expected)
+			}
 		})
 	}
 }
@@ -0,0 +1,80 @@
+package goose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Environment variables consulted when locating Goose data
+const (
+	// EnvDB overrides the Goose session databases (a path list, like PATH)
+	EnvDB = "BRAINDUMP_GOOSE_DB"
+	// EnvXDGDataHome is the XDG base directory Goose stores its data under
+	EnvXDGDataHome = "XDG_DATA_HOME"
+)
+
+// dbFileName is the name of the Goose sessions database
+const dbFileName = "sessions.db"
+
+// DefaultDBPaths returns the Goose databases to read when none are given.
+// BRAINDUMP_GOOSE_DB takes precedence, then $XDG_DATA_HOME/goose, then ~/.local/share/goose.
+func DefaultDBPaths() ([]string, error) {
+	if paths := envDBPaths(); len(paths) > 0 {
+		return paths, nil
+	}
+
+	dataHome := os.Getenv(EnvXDGDataHome)
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return []string{filepath.Join(dataHome, "goose", "sessions", dbFileName)}, nil
+}
+
+// envDBPaths returns the Goose databases chosen in the environment, or nil
+func envDBPaths() []string {
+	return filepath.SplitList(os.Getenv(EnvDB))
+}
+
+// hasData reports whether a configured path holds a sessions database or
+// legacy session files
+func hasData(path string) bool {
+	if _, err := os.Stat(dbFile(path)); err == nil {
+		return true
+	}
+	files, _ := legacyFiles(legacyDir(path))
+	return len(files) > 0
+}
+
+// noDataError explains why a path chosen by the user holds no Goose sessions
+func noDataError(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s holds no Goose sessions database or legacy session files", path)
+}
+
+// legacyDir returns the directory holding legacy JSONL session files for a
+// configured path: the Goose sessions directory the sessions database lives
+// in. A database with another name is not in a Goose sessions directory, so
+// "" is returned and JSONL files next to it are left alone.
+func legacyDir(path string) string {
+	db := dbFile(path)
+	if filepath.Base(db) != dbFileName {
+		return ""
+	}
+	return filepath.Dir(db)
+}
+
+// dbFile resolves a configured path to a database file.
+// A directory is taken to be a Goose sessions directory containing sessions.db.
+func dbFile(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, dbFileName)
+	}
+	return path
+}
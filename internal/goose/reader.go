@@ -4,166 +4,383 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"iter"
 	"os"
-	"path/filepath"
+	"strconv"
 	"time"
 
-	"github.com/block/braindump/internal/model"
-	_ "modernc.org/sqlite" // Import sqlite driver for database/sql
+	"github.com/block/braindump/cache"
+	"github.com/block/braindump/diagnostics"
+	"github.com/block/braindump/internal/timestamp"
+	"github.com/block/braindump/model"
 )
 
+// Options configures a Goose reader
+type Options struct {
+	// DBPaths are the Goose session databases to read. When empty, DefaultDBPaths is used.
+	// Paths given here or in the environment must hold sessions; the default
+	// database is skipped when it does not exist.
+	DBPaths []string
+	// Cache stores parsed messages between runs. Nil disables caching.
+	Cache *cache.Cache
+	// BusyTimeout is how long to wait for a lock held by a running Goose.
+	// Zero uses DefaultBusyTimeout.
+	BusyTimeout time.Duration
+	// Snapshot copies each database with the SQLite backup API and reads the copy
+	Snapshot bool
+	// Diagnostics collects rows and files that could not be fully read.
+	// Nil discards them.
+	Diagnostics *diagnostics.Collector
+}
+
 // Reader handles reading Goose sessions from SQLite
 type Reader struct {
-	homeDir string
+	dbPaths []string
+	// explicit is set when the user chose the databases, which must then exist
+	explicit    bool
+	cache       *cache.Cache
+	busyTimeout time.Duration
+	snapshot    bool
+	diagnostics *diagnostics.Collector
 }
 
 // NewReader creates a new Goose reader
-func NewReader() (*Reader, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+func NewReader(opts Options) (*Reader, error) {
+	dbPaths := opts.DBPaths
+	if len(dbPaths) == 0 {
+		dbPaths = envDBPaths()
+	}
+	explicit := len(dbPaths) > 0
+	if !explicit {
+		// Without a home directory there is no default to read
+		dbPaths, _ = DefaultDBPaths()
+	}
+
+	busyTimeout := opts.BusyTimeout
+	if busyTimeout <= 0 {
+		busyTimeout = DefaultBusyTimeout
+	}
+
+	return &Reader{
+		dbPaths:     dbPaths,
+		explicit:    explicit,
+		cache:       opts.Cache,
+		busyTimeout: busyTimeout,
+		snapshot:    opts.Snapshot,
+		diagnostics: opts.Diagnostics,
+	}, nil
+}
+
+// Name returns the agent type produced by this reader
+func (r *Reader) Name() string {
+	return "goose"
+}
+
+// report records a problem found while reading
+func (r *Reader) report(d model.Diagnostic) {
+	d.Agent = r.Name()
+	r.diagnostics.Add(d)
+}
+
+// Detect reports whether any Goose sessions database or legacy session file
+// exists. Databases chosen by the user are always read, so that missing ones
+// are reported.
+func (r *Reader) Detect() bool {
+	if r.explicit {
+		return true
+	}
+	for _, path := range r.dbPaths {
+		if hasData(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sessions yields Goose sessions from the SQLite databases in small batches,
+// followed by sessions from legacy JSONL files that are not in the database
+func (r *Reader) Sessions() iter.Seq2[model.Session, error] {
+	return func(yield func(model.Session, error) bool) {
+		for _, path := range r.dbPaths {
+			if r.explicit && !hasData(path) {
+				yield(model.Session{}, noDataError(path))
+				return
+			}
+			seen := make(map[string]bool)
+
+			stopped, err := r.readSessions(dbFile(path), seen, yield)
+			if err == nil && !stopped {
+				stopped, err = r.readLegacySessions(legacyDir(path), seen, yield)
+			}
+			if err != nil {
+				if isLocked(err) {
+					err = &lockedError{err: err}
+				}
+				yield(model.Session{}, fmt.Errorf("%s: %w", path, err))
+				return
+			}
+			if stopped {
+				return
+			}
+		}
 	}
-	return &Reader{homeDir: homeDir}, nil
 }
 
-// ReadSessions reads all Goose sessions from the SQLite database
-func (r *Reader) ReadSessions() ([]model.Session, error) {
-	dbPath := filepath.Join(r.homeDir, ".local", "share", "goose", "sessions", "sessions.db")
+// sessionBatchSize is how many sessions are read per transaction. Sessions
+// are yielded only once their transaction has ended, so a slow consumer never
+// holds a read lock that blocks Goose's writes or WAL checkpoints.
+const sessionBatchSize = 50
 
+// readSessions streams sessions from one database to yield, recording
+// their IDs in seen. It reports whether yield asked to stop.
+func (r *Reader) readSessions(dbPath string, seen map[string]bool, yield func(model.Session, error) bool) (bool, error) {
 	// Check if database exists
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		return []model.Session{}, nil // No Goose sessions
+		return false, nil // No Goose sessions
+	}
+
+	ctx := context.Background()
+
+	db, closeDB, err := openDB(ctx, dbPath, r.busyTimeout, r.snapshot)
+	if err != nil {
+		return false, err
 	}
+	defer closeDB()
 
-	db, err := sql.Open("sqlite", dbPath)
+	schema, ids, err := r.listSessions(ctx, db, dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return false, err
 	}
-	defer db.Close()
 
-	// Query sessions
-	sessionsQuery := `
-		SELECT id, name, description, user_set_name, session_type, working_dir,
-		       created_at, updated_at, extension_data, provider_name, model_config_json
-		FROM sessions
-		ORDER BY created_at DESC
-	`
+	for start := 0; start < len(ids); start += sessionBatchSize {
+		batch := ids[start:min(start+sessionBatchSize, len(ids))]
+		sessions, err := r.readBatch(ctx, db, schema, dbPath, batch)
+		if err != nil {
+			return false, err
+		}
 
-	ctx := context.Background()
-	rows, err := db.QueryContext(ctx, sessionsQuery)
+		for _, session := range sessions {
+			seen[session.SessionID] = true
+			if !yield(session, nil) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// listSessions reads the database's schema and the IDs of its sessions in
+// the order they are output
+func (r *Reader) listSessions(ctx context.Context, db *sql.DB, dbPath string) (*schema, []string, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
-		return nil, fmt.Errorf("failed to query sessions: %w", err)
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	defer rows.Close()
+	defer tx.Rollback() //nolint:errcheck // nothing was written
 
-	var sessions []model.Session
+	// Build queries from the columns this version of Goose created
+	schema, err := loadSchema(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := schema.checkVersion(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", dbPath, err)
+		r.report(model.Diagnostic{File: dbPath, Kind: model.DiagnosticNewerSchema, Message: err.Error()})
+	}
 
-	for rows.Next() {
-		var (
-			id              int
-			name            sql.NullString
-			description     sql.NullString
-			userSetName     sql.NullString
-			sessionType     sql.NullString
-			workingDir      sql.NullString
-			createdAt       sql.NullString
-			updatedAt       sql.NullString
-			extensionData   sql.NullString
-			providerName    sql.NullString
-			modelConfigJSON sql.NullString
-		)
+	rows, err := tx.QueryContext(ctx, schema.sessionIDsQuery())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
 
-		err := rows.Scan(&id, &name, &description, &userSetName, &sessionType,
-			&workingDir, &createdAt, &updatedAt, &extensionData, &providerName, &modelConfigJSON)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to scan session row: %v\n", err)
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			r.report(model.Diagnostic{File: dbPath, Kind: model.DiagnosticScanFailed, Message: err.Error()})
 			continue
 		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
 
-		// Parse timestamps
-		createdTime, _ := time.Parse(time.RFC3339, createdAt.String)
-		updatedTime, _ := time.Parse(time.RFC3339, updatedAt.String)
+	return schema, ids, nil
+}
 
-		// Parse model config
-		var modelConfig map[string]any
-		if modelConfigJSON.Valid && modelConfigJSON.String != "" {
-			_ = json.Unmarshal([]byte(modelConfigJSON.String), &modelConfig)
-		}
+// readBatch reads the sessions with the given IDs and their messages in one
+// transaction, so each session and its messages come from the same state of
+// the database even if Goose writes meanwhile. Sessions deleted since they
+// were listed are skipped.
+func (r *Reader) readBatch(ctx context.Context, db *sql.DB, schema *schema, dbPath string, ids []string) ([]model.Session, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // nothing was written
 
-		// Extract model name
-		modelName := ""
-		if modelConfig != nil {
-			if model, ok := modelConfig["model"].(string); ok {
-				modelName = model
-			}
+	var sessions []model.Session
+	for _, id := range ids {
+		session, ok, err := r.readSession(ctx, tx, schema, dbPath, id)
+		if err != nil {
+			return nil, err
 		}
-
-		// Build metadata
-		metadata := model.SessionMetadata{
-			WorkingDir: workingDir.String,
-			Provider:   providerName.String,
-			Model:      modelName,
-			Name:       name.String,
+		if ok {
+			sessions = append(sessions, session)
 		}
+	}
 
-		// Add extra metadata
-		metadata.Extra = make(map[string]string)
-		if userSetName.Valid {
-			metadata.Extra["user_set_name"] = userSetName.String
-		}
-		if sessionType.Valid {
-			metadata.Extra["session_type"] = sessionType.String
+	return sessions, nil
+}
+
+// readSession reads one session and its messages. It reports false when the
+// session is gone or could not be read, and returns only lock errors.
+func (r *Reader) readSession(ctx context.Context, tx querier, schema *schema, dbPath, id string) (model.Session, bool, error) {
+	var (
+		name            sql.NullString
+		description     sql.NullString
+		userSetName     sql.NullString
+		sessionType     sql.NullString
+		workingDir      sql.NullString
+		createdAt       sql.NullString
+		updatedAt       sql.NullString
+		extensionData   sql.NullString
+		providerName    sql.NullString
+		modelConfigJSON sql.NullString
+		input           *int64
+		output          *int64
+		total           *int64
+		accumInput      *int64
+		accumOutput     *int64
+		accumTotal      *int64
+		scheduleID      sql.NullString
+		recipeJSON      sql.NullString
+		recipeValues    sql.NullString
+	)
+
+	err := tx.QueryRowContext(ctx, schema.sessionQuery(), id).Scan(&id, &name, &description, &userSetName, &sessionType,
+		&workingDir, &createdAt, &updatedAt, &extensionData, &providerName, &modelConfigJSON,
+		&input, &output, &total, &accumInput, &accumOutput, &accumTotal,
+		&scheduleID, &recipeJSON, &recipeValues)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Session{}, false, nil
+	}
+	if isLocked(err) {
+		return model.Session{}, false, err
+	}
+	if err != nil {
+		r.report(model.Diagnostic{File: dbPath, SessionID: id, Kind: model.DiagnosticScanFailed, Message: err.Error()})
+		return model.Session{}, false, nil
+	}
+
+	// Parse timestamps
+	createdTime, err := parseTimestamp(createdAt)
+	if err != nil {
+		r.report(model.Diagnostic{File: dbPath, SessionID: id, Field: "created_at", Kind: model.DiagnosticInvalidTimestamp, Message: err.Error()})
+	}
+	updatedTime, err := parseTimestamp(updatedAt)
+	if err != nil {
+		r.report(model.Diagnostic{File: dbPath, SessionID: id, Field: "updated_at", Kind: model.DiagnosticInvalidTimestamp, Message: err.Error()})
+	}
+
+	// JSON columns that cannot be decoded are left out of the metadata
+	problem := func(field string, err error) {
+		r.report(model.Diagnostic{File: dbPath, SessionID: id, Field: field, Kind: model.DiagnosticMalformedRecord, Message: err.Error()})
+	}
+
+	// Parse model config
+	var modelConfig map[string]any
+	if modelConfigJSON.Valid && modelConfigJSON.String != "" {
+		if err := json.Unmarshal([]byte(modelConfigJSON.String), &modelConfig); err != nil {
+			problem("model_config_json", err)
 		}
-		if description.Valid {
-			metadata.Extra["description"] = description.String
+	}
+
+	// Extract model name
+	modelName := ""
+	if modelConfig != nil {
+		if model, ok := modelConfig["model"].(string); ok {
+			modelName = model
 		}
+	}
 
-		// Read messages for this session
-		messages, err := r.readMessages(db, id)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to read messages for session %d: %v\n", id, err)
-			continue
+	// Build metadata
+	metadata := model.SessionMetadata{
+		WorkingDir:        workingDir.String,
+		Provider:          providerName.String,
+		Model:             modelName,
+		Name:              name.String,
+		Tokens:            tokenUsage(input, output, total),
+		AccumulatedTokens: tokenUsage(accumInput, accumOutput, accumTotal),
+		ScheduleID:        scheduleID.String,
+		Recipe:            parseRecipe([]byte(recipeJSON.String), []byte(recipeValues.String), problem),
+	}
+	if extensionData.Valid && extensionData.String != "" {
+		if err := parseExtensionData([]byte(extensionData.String), &metadata); err != nil {
+			problem("extension_data", err)
 		}
+	}
 
-		sessions = append(sessions, model.Session{
-			AgentType: "goose",
-			SessionID: fmt.Sprintf("%d", id),
-			CreatedAt: createdTime,
-			UpdatedAt: updatedTime,
-			Metadata:  metadata,
-			Messages:  messages,
-		})
+	// Add extra metadata
+	metadata.Extra = make(map[string]string)
+	if userSetName.Valid {
+		metadata.Extra["user_set_name"] = userSetName.String
+	}
+	if sessionType.Valid {
+		metadata.Extra["session_type"] = sessionType.String
+	}
+	if description.Valid {
+		metadata.Extra["description"] = description.String
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	// Read messages for this session
+	messages, problems, err := r.cachedMessages(tx, schema, dbPath, id, updatedAt.String)
+	if isLocked(err) {
+		return model.Session{}, false, err
+	}
+	if err != nil {
+		r.report(model.Diagnostic{File: dbPath, SessionID: id, Kind: model.DiagnosticReadFailed, Message: err.Error()})
+		return model.Session{}, false, nil
+	}
+	for _, d := range problems {
+		d.File = dbPath
+		r.report(d)
 	}
 
-	return sessions, nil
+	return model.Session{
+		AgentType: "goose",
+		SessionID: id,
+		CreatedAt: createdTime,
+		UpdatedAt: updatedTime,
+		Metadata:  metadata,
+		Messages:  messages,
+	}, true, nil
 }
 
-// readMessages reads messages for a specific session
-func (r *Reader) readMessages(db *sql.DB, sessionID int) ([]model.Message, error) {
-	messagesQuery := `
-		SELECT id, message_id, role, content_json, created_timestamp, tokens, metadata_json
-		FROM messages
-		WHERE session_id = ?
-		ORDER BY created_timestamp ASC
-	`
-
+// readMessages reads messages for a specific session, and the problems found
+// reading them without the database's path
+func (r *Reader) readMessages(db querier, schema *schema, sessionID string) ([]model.Message, []model.Diagnostic, error) {
 	ctx := context.Background()
-	rows, err := db.QueryContext(ctx, messagesQuery, sessionID)
+	rows, err := db.QueryContext(ctx, schema.messagesQuery(), sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query messages: %w", err)
+		return nil, nil, fmt.Errorf("failed to query messages: %w", err)
 	}
 	defer rows.Close()
 
 	var messages []model.Message
+	var problems []model.Diagnostic
+	problem := func(row, field, kind string, err error) {
+		problems = append(problems, model.Diagnostic{SessionID: sessionID, Row: row, Field: field, Kind: kind, Message: err.Error()})
+	}
 
 	for rows.Next() {
 		var (
-			id               int
+			id               sql.NullInt64
 			messageID        sql.NullString
 			role             string
 			contentJSON      sql.NullString
@@ -174,16 +391,27 @@ func (r *Reader) readMessages(db *sql.DB, sessionID int) ([]model.Message, error
 
 		err := rows.Scan(&id, &messageID, &role, &contentJSON, &createdTimestamp, &tokens, &metadataJSON)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to scan message row: %v\n", err)
+			problem("", "", model.DiagnosticScanFailed, err)
 			continue
 		}
 
+		row := messageID.String
+		if row == "" && id.Valid {
+			row = strconv.FormatInt(id.Int64, 10)
+		}
+
 		// Parse timestamp
-		timestamp, _ := time.Parse(time.RFC3339, createdTimestamp.String)
+		createdTime, err := parseTimestamp(createdTimestamp)
+		if err != nil {
+			problem(row, firstColumn(schema.messages, messageTimestampColumns), model.DiagnosticInvalidTimestamp, err)
+		}
 
 		// Parse content
 		var contentBlocks []model.ContentBlock
 		if contentJSON.Valid && contentJSON.String != "" {
+			if !json.Valid([]byte(contentJSON.String)) {
+				problem(row, firstColumn(schema.messages, []string{"content_json", "content"}), model.DiagnosticMalformedRecord, errors.New("content is not valid JSON"))
+			}
 			contentBlocks = parseContent(contentJSON.String)
 		}
 
@@ -199,23 +427,30 @@ func (r *Reader) readMessages(db *sql.DB, sessionID int) ([]model.Message, error
 		if metadataJSON.Valid && metadataJSON.String != "" {
 			var extraMetadata map[string]any
 			if err := json.Unmarshal([]byte(metadataJSON.String), &extraMetadata); err == nil {
-				metadata.Extra = make(map[string]string)
-				for k, v := range extraMetadata {
-					if str, ok := v.(string); ok {
-						metadata.Extra[k] = str
-					}
-				}
+				parseMessageMetadata(extraMetadata, &metadata)
+			} else {
+				problem(row, firstColumn(schema.messages, []string{"metadata_json", "metadata"}), model.DiagnosticMalformedRecord, err)
 			}
 		}
 
 		messages = append(messages, model.Message{
 			UUID:      messageID.String,
-			Timestamp: timestamp,
+			Timestamp: createdTime,
 			Role:      role,
 			Content:   contentBlocks,
 			Metadata:  metadata,
 		})
 	}
 
-	return messages, rows.Err()
+	return messages, problems, rows.Err()
+}
+
+// parseTimestamp parses a timestamp column, which Goose fills with SQLite
+// CURRENT_TIMESTAMP text, RFC 3339 or Unix seconds depending on the column
+// and version. NULL and empty values are the zero time.
+func parseTimestamp(value sql.NullString) (time.Time, error) {
+	if !value.Valid || value.String == "" {
+		return time.Time{}, nil
+	}
+	return timestamp.Parse(value.String)
 }
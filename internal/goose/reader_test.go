@@ -0,0 +1,475 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/block/braindump/diagnostics"
+	"github.com/block/braindump/model"
+	"github.com/block/braindump/source"
+)
+
+// testSchema is the sessions database layout written by current Goose versions
+const testSchema = `
+	CREATE TABLE sessions (
+		id TEXT PRIMARY KEY, name TEXT, description TEXT, user_set_name BOOLEAN, session_type TEXT,
+		working_dir TEXT, created_at TEXT, updated_at TEXT, extension_data TEXT,
+		provider_name TEXT, model_config_json TEXT, input_tokens INTEGER, output_tokens INTEGER,
+		total_tokens INTEGER, accumulated_input_tokens INTEGER, accumulated_output_tokens INTEGER,
+		accumulated_total_tokens INTEGER, schedule_id TEXT, recipe_json TEXT, user_recipe_values_json TEXT
+	);
+	CREATE TABLE messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, message_id TEXT, session_id TEXT, role TEXT,
+		content_json TEXT, created_timestamp TEXT, tokens INTEGER, metadata_json TEXT
+	);`
+
+// writeTestDB creates a sessions database at path with the given schema and statements
+func writeTestDB(tb testing.TB, path, schema string, statements ...string) {
+	tb.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		tb.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	for _, stmt := range append([]string{schema}, statements...) {
+		if _, err := db.ExecContext(tb.Context(), stmt); err != nil {
+			tb.Fatalf("exec %q: %v", stmt, err)
+		}
+	}
+}
+
+// readAll reads every session from the databases at paths, without caching
+func readAll(tb testing.TB, paths ...string) []model.Session {
+	tb.Helper()
+
+	reader, err := NewReader(Options{DBPaths: paths})
+	if err != nil {
+		tb.Fatalf("NewReader: %v", err)
+	}
+
+	var sessions []model.Session
+	for session, err := range reader.Sessions() {
+		if err != nil {
+			tb.Fatalf("Sessions: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+func TestReaderSessions(t *testing.T) {
+	dir := t.TempDir()
+	writeTestDB(t, filepath.Join(dir, dbFileName), testSchema,
+		`INSERT INTO sessions (id, name, working_dir, created_at, updated_at, provider_name, model_config_json)
+		 VALUES ('20250101_1', 'Fix build', '/src', '2025-01-01T10:00:00Z', '2025-01-01T10:05:00Z', 'anthropic', '{"model":"claude-sonnet-4-5"}')`,
+		`INSERT INTO messages (message_id, session_id, role, content_json, created_timestamp, tokens, metadata_json)
+		 VALUES ('msg_1', '20250101_1', 'user', '[{"type":"text","text":"fix the build"}]', '2025-01-01T10:00:00Z', 12,
+			'{"userVisible":false,"agentVisible":true,"source":"hint","priority":2}')`,
+	)
+
+	sessions := readAll(t, dir)
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+
+	session := sessions[0]
+	if session.SessionID != "20250101_1" || session.Metadata.Name != "Fix build" || session.Metadata.Model != "claude-sonnet-4-5" {
+		t.Errorf("unexpected session: %+v", session)
+	}
+	if len(session.Messages) != 1 || session.Messages[0].Content[0].Text != "fix the build" {
+		t.Fatalf("unexpected messages: %+v", session.Messages)
+	}
+
+	metadata := session.Messages[0].Metadata
+	if metadata.VisibleToUser() || !metadata.VisibleToAgent() {
+		t.Errorf("visibility = user %v, agent %v; want false, true", metadata.VisibleToUser(), metadata.VisibleToAgent())
+	}
+	if want := map[string]any{"source": "hint", "priority": float64(2)}; !reflect.DeepEqual(metadata.Extra, want) {
+		t.Errorf("extra = %v, want %v", metadata.Extra, want)
+	}
+}
+
+func TestReaderSessionMetadata(t *testing.T) {
+	dir := t.TempDir()
+	writeTestDB(t, filepath.Join(dir, dbFileName), testSchema,
+		`INSERT INTO sessions (id, created_at, updated_at, extension_data, input_tokens, output_tokens, total_tokens,
+			accumulated_input_tokens, accumulated_output_tokens, accumulated_total_tokens, schedule_id, recipe_json, user_recipe_values_json)
+		 VALUES ('s1', '2025-01-01T10:00:00Z', '2025-01-01T10:05:00Z',
+			'{"enabled_extensions.v0":{"extensions":[{"type":"builtin","name":"developer","timeout":300,"bundled":true},{"type":"stdio","name":"github","cmd":"gh-mcp","args":[]}]},"todo.v0":{"content":"- [x] reproduce\n- [ ] fix"},"memory.v1":{"notes":["prefers tabs"]}}',
+			1200, 300, 1500, 9000, 2000, 11000, 'nightly-triage',
+			'{"version":"1.0.0","title":"Triage","description":"Triage new issues","instructions":"...","parameters":[{"key":"repo","input_type":"string"}]}',
+			'{"repo":"block/goose"}')`,
+		`INSERT INTO sessions (id, created_at, updated_at, extension_data) VALUES ('s0', '2024-01-01T10:00:00Z', '2024-01-01T10:00:00Z', '{}')`,
+	)
+
+	sessions := readAll(t, dir)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	want := model.SessionMetadata{
+		Tokens:            &model.TokenUsage{InputTokens: 1200, OutputTokens: 300, TotalTokens: 1500},
+		AccumulatedTokens: &model.TokenUsage{InputTokens: 9000, OutputTokens: 2000, TotalTokens: 11000},
+		Extensions: []model.Extension{
+			{Name: "developer", Type: "builtin"},
+			{Name: "github", Type: "stdio"},
+		},
+		Todo:           "- [x] reproduce\n- [ ] fix",
+		ExtensionState: map[string]any{"memory.v1": map[string]any{"notes": []any{"prefers tabs"}}},
+		ScheduleID:     "nightly-triage",
+		Recipe: &model.Recipe{
+			Title:       "Triage",
+			Description: "Triage new issues",
+			Version:     "1.0.0",
+			Parameters:  map[string]string{"repo": "block/goose"},
+		},
+		Extra: map[string]string{},
+	}
+	if got := sessions[0].Metadata; !reflect.DeepEqual(got, want) {
+		t.Errorf("metadata =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := sessions[1].Metadata; got.Tokens != nil || got.Extensions != nil || got.ExtensionState != nil || got.Recipe != nil {
+		t.Errorf("expected no token, extension or recipe metadata, got %+v", got)
+	}
+}
+
+func TestReaderOlderSchema(t *testing.T) {
+	dir := t.TempDir()
+	writeTestDB(t, filepath.Join(dir, dbFileName), `
+		CREATE TABLE sessions (id TEXT PRIMARY KEY, description TEXT, working_dir TEXT, created_at TEXT, updated_at TEXT);
+		CREATE TABLE messages (session_id TEXT, role TEXT, content TEXT, timestamp TEXT);`,
+		`INSERT INTO sessions VALUES ('s1', 'Old session', '/src', '2024-06-01T10:00:00Z', '2024-06-01T10:01:00Z')`,
+		`INSERT INTO messages VALUES ('s1', 'assistant', '[{"type":"text","text":"second"}]', '2024-06-01T10:01:00Z')`,
+		`INSERT INTO messages VALUES ('s1', 'user', '[{"type":"text","text":"first"}]', '2024-06-01T10:00:00Z')`,
+	)
+
+	sessions := readAll(t, dir)
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+
+	session := sessions[0]
+	if session.Metadata.WorkingDir != "/src" || session.Metadata.Extra["description"] != "Old session" {
+		t.Errorf("unexpected metadata: %+v", session.Metadata)
+	}
+	if len(session.Messages) != 2 || session.Messages[0].Content[0].Text != "first" {
+		t.Errorf("expected messages in timestamp order, got %+v", session.Messages)
+	}
+}
+
+func TestReaderUnsupportedSchema(t *testing.T) {
+	dir := t.TempDir()
+	writeTestDB(t, filepath.Join(dir, dbFileName), `
+		CREATE TABLE sessions (id TEXT PRIMARY KEY);
+		CREATE TABLE messages (id INTEGER PRIMARY KEY, conversation TEXT, role TEXT);`)
+
+	reader, err := NewReader(Options{DBPaths: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var readErr error
+	for _, err := range reader.Sessions() {
+		readErr = err
+	}
+	if readErr == nil || !strings.Contains(readErr.Error(), "messages table has no session_id column") {
+		t.Errorf("expected a missing column error, got %v", readErr)
+	}
+}
+
+func TestReaderTimestampFormats(t *testing.T) {
+	dir := t.TempDir()
+	writeTestDB(t, filepath.Join(dir, dbFileName), `
+		CREATE TABLE sessions (id TEXT PRIMARY KEY, created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, updated_at TEXT);
+		CREATE TABLE messages (id INTEGER PRIMARY KEY, session_id TEXT, role TEXT, content_json TEXT, created_timestamp INTEGER);`,
+		`INSERT INTO sessions (id, created_at, updated_at) VALUES ('s1', '2025-01-01 10:00:00', '2025-01-01 10:05:00.250')`,
+		`INSERT INTO messages (session_id, role, content_json, created_timestamp)
+		 VALUES ('s1', 'user', '[]', 1735725600), ('s1', 'assistant', '[]', 1735725660000)`,
+	)
+
+	sessions := readAll(t, dir)
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+
+	session := sessions[0]
+	created := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	if !session.CreatedAt.Equal(created) || !session.UpdatedAt.Equal(created.Add(5*time.Minute+250*time.Millisecond)) {
+		t.Errorf("session times = %v - %v", session.CreatedAt, session.UpdatedAt)
+	}
+	if len(session.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(session.Messages))
+	}
+	// Unix seconds and milliseconds
+	if got := session.Messages[0].Timestamp; !got.Equal(created) {
+		t.Errorf("first message time = %v, want %v", got, created)
+	}
+	if got := session.Messages[1].Timestamp; !got.Equal(created.Add(time.Minute)) {
+		t.Errorf("second message time = %v, want %v", got, created.Add(time.Minute))
+	}
+}
+
+func TestReaderDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, dbFileName)
+	writeTestDB(t, dbPath, testSchema,
+		`CREATE TABLE schema_version (version INTEGER PRIMARY KEY)`,
+		`INSERT INTO schema_version (version) VALUES (99)`,
+		`INSERT INTO sessions (id, created_at, updated_at, model_config_json, extension_data, recipe_json, user_recipe_values_json)
+		 VALUES ('s1', '2025-01-01T10:00:00Z', 'later', '{"model":', '[1]', '{"title":"Triage"}', 'not json')`,
+		`INSERT INTO messages (message_id, session_id, role, content_json, created_timestamp, metadata_json)
+		 VALUES ('m1', 's1', 'user', '[{"type":"text","text":"hi"}]', 'soon', '{"userVisible":'),
+		        (NULL, 's1', 'assistant', '[{"type":', '2025-01-01T10:00:01Z', NULL)`,
+	)
+	legacyPath := filepath.Join(dir, "old.jsonl")
+	legacy := `{"id":"m1","role":"user","created":1714557600,"content":[{"type":"text","text":"hello"}]}` + "\n{truncated\n" +
+		`{"id":"m2","role":"assistant","created":"2024-05-01T10:00:00Z","content":[]}` + "\n"
+	metaPath := filepath.Join(dir, "meta.jsonl")
+	for path, content := range map[string]string{legacyPath: legacy, metaPath: `{"working_dir":7}` + "\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(metaPath, time.Unix(1000, 0), time.Unix(1000, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	collector := diagnostics.NewCollector()
+	reader, err := NewReader(Options{DBPaths: []string{dir}, Diagnostics: collector})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range reader.Sessions() {
+		if err != nil {
+			t.Fatalf("Sessions: %v", err)
+		}
+	}
+
+	var got []string
+	for _, d := range collector.Diagnostics() {
+		if d.Agent != "goose" {
+			t.Errorf("agent = %q, want goose", d.Agent)
+		}
+		got = append(got, fmt.Sprintf("%s %s:%d %s/%s/%s", d.Kind, filepath.Base(d.File), d.Line, d.SessionID, d.Row, d.Field))
+	}
+	want := []string{
+		"newer_schema sessions.db:0 //",
+		"invalid_timestamp sessions.db:0 s1//updated_at",
+		"malformed_record sessions.db:0 s1//model_config_json",
+		"malformed_record sessions.db:0 s1//user_recipe_values_json",
+		"malformed_record sessions.db:0 s1//extension_data",
+		// Messages are read in timestamp order
+		"malformed_record sessions.db:0 s1/2/content_json",
+		"invalid_timestamp sessions.db:0 s1/m1/created_timestamp",
+		"malformed_record sessions.db:0 s1/m1/metadata_json",
+		"malformed_record old.jsonl:2 old//",
+		"malformed_record old.jsonl:3 old//",
+		"malformed_record meta.jsonl:1 meta//working_dir",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestLoadSchemaVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), dbFileName)
+	writeTestDB(t, path, testSchema,
+		`CREATE TABLE schema_version (version INTEGER PRIMARY KEY, applied_at TIMESTAMP)`,
+		`INSERT INTO schema_version (version) VALUES (1), (2), (3)`,
+	)
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	schema, err := loadSchema(t.Context(), db)
+	if err != nil {
+		t.Fatalf("loadSchema: %v", err)
+	}
+	if schema.version != 3 {
+		t.Errorf("version = %d, want 3", schema.version)
+	}
+	if !schema.sessions["model_config_json"] || !schema.messages["content_json"] {
+		t.Errorf("columns not introspected: %v %v", schema.sessions, schema.messages)
+	}
+	if got := selectList(map[string]bool{"id": true, "timestamp": true}, messageColumns); got != "id, NULL, NULL, NULL, timestamp, NULL, NULL" {
+		t.Errorf("selectList = %q", got)
+	}
+}
+
+func TestReaderSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	writeTestDB(t, filepath.Join(dir, dbFileName), testSchema,
+		`PRAGMA journal_mode=WAL`,
+		`INSERT INTO sessions (id, created_at, updated_at) VALUES ('s1', '2025-01-01T10:00:00Z', '2025-01-01T10:05:00Z')`,
+		`INSERT INTO messages (session_id, role, content_json) VALUES ('s1', 'user', '[{"type":"text","text":"hi"}]')`,
+	)
+
+	reader, err := NewReader(Options{DBPaths: []string{dir}, Snapshot: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sessions []model.Session
+	for session, err := range reader.Sessions() {
+		if err != nil {
+			t.Fatalf("Sessions: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if len(sessions) != 1 || len(sessions[0].Messages) != 1 {
+		t.Fatalf("unexpected sessions from snapshot: %+v", sessions)
+	}
+
+	if leftover, _ := filepath.Glob(filepath.Join(tmp, "*")); len(leftover) > 0 {
+		t.Errorf("snapshot not removed: %v", leftover)
+	}
+}
+
+func TestReaderLocked(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, dbFileName)
+	writeTestDB(t, path, testSchema)
+
+	// Hold an exclusive lock, as Goose does while committing in rollback journal mode
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	conn, err := db.Conn(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(t.Context(), "BEGIN EXCLUSIVE"); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.ExecContext(t.Context(), "ROLLBACK") //nolint:errcheck // test cleanup
+
+	reader, err := NewReader(Options{DBPaths: []string{dir}, BusyTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var readErr error
+	for _, err := range reader.Sessions() {
+		readErr = err
+	}
+	if !errors.Is(readErr, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", readErr)
+	}
+	var hinted source.HintError
+	if !errors.As(readErr, &hinted) || !strings.Contains(hinted.Hint(), "--goose-snapshot") {
+		t.Errorf("expected a hint to use --goose-snapshot, got %v", readErr)
+	}
+}
+
+func TestReaderReleasesLockBeforeYield(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, dbFileName)
+
+	// More sessions than fit in one batch
+	count := sessionBatchSize + 10
+	statements := make([]string, count)
+	for i := range count {
+		statements[i] = fmt.Sprintf(`INSERT INTO sessions (id, created_at) VALUES ('s%03d', '2025-01-01T10:%02d:%02dZ')`, i, i/60, i%60)
+	}
+	writeTestDB(t, path, testSchema, statements...)
+
+	reader, err := NewReader(Options{DBPaths: []string{dir}, BusyTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A writer without a busy timeout fails at once if a reader holds a lock
+	writer, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	var ids []string
+	for session, err := range reader.Sessions() {
+		if err != nil {
+			t.Fatalf("Sessions: %v", err)
+		}
+		if len(ids) == 0 || len(ids) == sessionBatchSize {
+			if _, err := writer.ExecContext(t.Context(), `UPDATE sessions SET name = 'renamed' WHERE id = ?`, session.SessionID); err != nil {
+				t.Fatalf("write while consuming session %d: %v", len(ids), err)
+			}
+		}
+		ids = append(ids, session.SessionID)
+	}
+
+	if len(ids) != count {
+		t.Fatalf("expected %d sessions, got %d", count, len(ids))
+	}
+	for i, id := range ids {
+		if want := fmt.Sprintf("s%03d", count-1-i); id != want {
+			t.Fatalf("session %d = %s, want %s", i, id, want)
+		}
+	}
+}
+
+func TestReaderWithoutData(t *testing.T) {
+	empty := t.TempDir()
+	missing := filepath.Join(t.TempDir(), "nope.db")
+
+	for _, tc := range []struct {
+		name string
+		opts Options
+		env  string
+		want string
+	}{
+		{name: "empty flag dir", opts: Options{DBPaths: []string{empty}}, want: "holds no Goose sessions"},
+		{name: "missing flag", opts: Options{DBPaths: []string{missing}}, want: "no such file"},
+		{name: "missing env", env: missing, want: "no such file"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(EnvDB, tc.env)
+
+			reader, err := NewReader(tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reader.Detect() {
+				t.Error("Detect: got false for a database chosen by the user")
+			}
+
+			var readErr error
+			for _, err := range reader.Sessions() {
+				readErr = err
+			}
+			if readErr == nil || !strings.Contains(readErr.Error(), tc.want) {
+				t.Errorf("Sessions: got error %v, want one containing %q", readErr, tc.want)
+			}
+		})
+	}
+
+	// The default database is skipped when Goose was never run
+	t.Setenv(EnvDB, "")
+	t.Setenv(EnvXDGDataHome, empty)
+	reader, err := NewReader(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Detect() {
+		t.Error("Detect: got true without a default database")
+	}
+}
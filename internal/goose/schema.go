@@ -0,0 +1,201 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// latestSchemaVersion is the newest Goose schema version braindump has been
+// checked against. Newer databases are still read, with a diagnostic.
+const latestSchemaVersion = 6
+
+// messageTimestampColumns are the names the message timestamp has had
+var messageTimestampColumns = []string{"created_timestamp", "timestamp", "created_at"}
+
+// Columns read from the sessions and messages tables, in scan order. Each
+// entry lists the names the column has had across Goose versions; columns
+// missing from a database are read as NULL.
+var (
+	sessionColumns = [][]string{
+		{"id"},
+		{"name"},
+		{"description"},
+		{"user_set_name"},
+		{"session_type"},
+		{"working_dir"},
+		{"created_at"},
+		{"updated_at"},
+		{"extension_data"},
+		{"provider_name"},
+		{"model_config_json"},
+		{"input_tokens"},
+		{"output_tokens"},
+		{"total_tokens"},
+		{"accumulated_input_tokens"},
+		{"accumulated_output_tokens"},
+		{"accumulated_total_tokens"},
+		{"schedule_id"},
+		{"recipe_json"},
+		{"user_recipe_values_json"},
+	}
+
+	messageColumns = [][]string{
+		{"id"},
+		{"message_id"},
+		{"role"},
+		{"content_json", "content"},
+		messageTimestampColumns,
+		{"tokens"},
+		{"metadata_json", "metadata"},
+	}
+)
+
+// Columns without which a table cannot be read
+var (
+	requiredSessionColumns = []string{"id"}
+	requiredMessageColumns = []string{"session_id", "role"}
+)
+
+// querier is a database or transaction to read from
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// schema describes the layout of a Goose sessions database
+type schema struct {
+	// version is the schema version Goose recorded, or 0 if it records none
+	version  int
+	sessions map[string]bool
+	messages map[string]bool
+}
+
+// loadSchema introspects the tables of a sessions database
+func loadSchema(ctx context.Context, db querier) (*schema, error) {
+	s := &schema{}
+
+	var err error
+	if s.sessions, err = tableColumns(ctx, db, "sessions"); err != nil {
+		return nil, err
+	}
+	if s.messages, err = tableColumns(ctx, db, "messages"); err != nil {
+		return nil, err
+	}
+
+	if err := requireColumns("sessions", s.sessions, requiredSessionColumns); err != nil {
+		return nil, err
+	}
+	if err := requireColumns("messages", s.messages, requiredMessageColumns); err != nil {
+		return nil, err
+	}
+
+	// Goose records applied migrations in schema_version; older databases have no such table
+	versions, err := tableColumns(ctx, db, "schema_version")
+	if err != nil {
+		return nil, err
+	}
+	if versions["version"] {
+		var version sql.NullInt64
+		if err := db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to read schema version: %w", err)
+		}
+		s.version = int(version.Int64)
+	}
+
+	return s, nil
+}
+
+// tableColumns returns the columns of a table, or none if the table does not exist
+func tableColumns(ctx context.Context, db querier, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to inspect %s table: %w", table, err)
+		}
+		columns[strings.ToLower(name)] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// requireColumns checks that a table has the columns needed to read it
+func requireColumns(table string, columns map[string]bool, required []string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("no %s table; this does not look like a Goose sessions database", table)
+	}
+
+	var missing []string
+	for _, column := range required {
+		if !columns[column] {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("unsupported Goose schema: %s table has no %s column", table, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkVersion returns an error for schema versions newer than braindump knows about
+func (s *schema) checkVersion() error {
+	if s.version > latestSchemaVersion {
+		return fmt.Errorf("schema version %d is newer than the latest supported (%d); some fields may be missing",
+			s.version, latestSchemaVersion)
+	}
+	return nil
+}
+
+// sessionIDsQuery selects the IDs of all sessions, newest first when the
+// table records creation times
+func (s *schema) sessionIDsQuery() string {
+	order := "id"
+	if s.sessions["created_at"] {
+		order = "created_at DESC"
+	}
+	return "SELECT id FROM sessions ORDER BY " + order
+}
+
+// sessionQuery selects sessionColumns from one session
+func (s *schema) sessionQuery() string {
+	return "SELECT " + selectList(s.sessions, sessionColumns) + " FROM sessions WHERE id = ?"
+}
+
+// messagesQuery selects messageColumns from the messages of one session
+func (s *schema) messagesQuery() string {
+	order := "rowid"
+	if column := firstColumn(s.messages, messageTimestampColumns); column != "" {
+		order = column + " ASC, rowid"
+	}
+	return "SELECT " + selectList(s.messages, messageColumns) + " FROM messages WHERE session_id = ? ORDER BY " + order
+}
+
+// selectList builds a select list for columns, using NULL for missing columns
+func selectList(available map[string]bool, columns [][]string) string {
+	exprs := make([]string, len(columns))
+	for i, names := range columns {
+		exprs[i] = "NULL"
+		if column := firstColumn(available, names); column != "" {
+			exprs[i] = column
+		}
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// firstColumn returns the first of names that is an available column, or ""
+func firstColumn(available map[string]bool, names []string) string {
+	for _, name := range names {
+		if available[name] {
+			return name
+		}
+	}
+	return ""
+}
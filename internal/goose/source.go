@@ -0,0 +1,24 @@
+package goose
+
+import (
+	"github.com/block/braindump/source"
+	"github.com/spf13/pflag"
+)
+
+// Register adds the Goose source to registry, with the flags that choose
+// which databases it reads and how it waits for a running Goose
+func Register(registry *source.Registry) {
+	var opts Options
+
+	registry.Register("goose", func(cfg source.Config) (source.Source, error) {
+		opts := opts
+		opts.Cache = cfg.Cache
+		opts.Diagnostics = cfg.Diagnostics
+		return NewReader(opts)
+	})
+	registry.RegisterFlags("goose", func(fs *pflag.FlagSet) {
+		fs.StringSliceVar(&opts.DBPaths, "goose-db", nil, "Goose sessions database to read (repeatable; default: $"+EnvDB+" or $XDG_DATA_HOME/goose/sessions/sessions.db)")
+		fs.DurationVar(&opts.BusyTimeout, "goose-busy-timeout", DefaultBusyTimeout, "How long to wait for a running Goose to release a lock on its database")
+		fs.BoolVar(&opts.Snapshot, "goose-snapshot", false, "Copy each Goose database with the SQLite backup API and read the copy")
+	})
+}
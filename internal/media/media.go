@@ -0,0 +1,167 @@
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/block/braindump/model"
+)
+
+// extensions maps common media types to file extensions, for types where
+// the standard library's choice is ambiguous or missing
+var extensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+}
+
+// Extractor writes base64 image and document data to files in a directory
+// and replaces the inline data with the file path. Files are named by a hash
+// of their content, so an attachment repeated across sessions is written once.
+type Extractor struct {
+	dir string
+}
+
+// NewExtractor creates an extractor writing to dir, which is created if needed
+func NewExtractor(dir string) *Extractor {
+	return &Extractor{dir: dir}
+}
+
+// Session extracts the media in a session's messages, including its subagents.
+// The session passed in is not modified. A nil extractor returns the session unchanged.
+func (e *Extractor) Session(session model.Session) (model.Session, error) {
+	if e == nil {
+		return session, nil
+	}
+
+	var err error
+
+	session.Messages, err = e.messages(session.Messages)
+	if err != nil {
+		return session, err
+	}
+
+	if session.Subagents != nil {
+		subagents := make([]model.Subagent, len(session.Subagents))
+		for i, subagent := range session.Subagents {
+			subagent.Messages, err = e.messages(subagent.Messages)
+			if err != nil {
+				return session, err
+			}
+			subagents[i] = subagent
+		}
+		session.Subagents = subagents
+	}
+
+	return session, nil
+}
+
+// messages returns messages with their media extracted
+func (e *Extractor) messages(messages []model.Message) ([]model.Message, error) {
+	if messages == nil {
+		return nil, nil
+	}
+
+	extracted := make([]model.Message, len(messages))
+	for i, msg := range messages {
+		content, err := e.blocks(msg.Content)
+		if err != nil {
+			return nil, err
+		}
+		msg.Content = content
+		extracted[i] = msg
+	}
+
+	return extracted, nil
+}
+
+// blocks returns content blocks with their media extracted
+func (e *Extractor) blocks(blocks []model.ContentBlock) ([]model.ContentBlock, error) {
+	if blocks == nil {
+		return nil, nil
+	}
+
+	extracted := make([]model.ContentBlock, len(blocks))
+	for i, block := range blocks {
+		block, err := e.block(block)
+		if err != nil {
+			return nil, err
+		}
+		extracted[i] = block
+	}
+
+	return extracted, nil
+}
+
+// block extracts the data of a single base64 image or document block,
+// or of the media within a tool result
+func (e *Extractor) block(block model.ContentBlock) (model.ContentBlock, error) {
+	if block.Type == "tool_result" {
+		parts, err := e.blocks(block.Parts)
+		if err != nil {
+			return block, err
+		}
+		block.Parts = parts
+		return block, nil
+	}
+
+	if block.Type != "image" && block.Type != "document" {
+		return block, nil
+	}
+	if block.SourceType != "base64" || block.Data == "" {
+		return block, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(block.Data)
+	if err != nil {
+		// Leave undecodable data inline rather than failing the export
+		return block, nil //nolint:nilerr // malformed attachments are kept as-is
+	}
+
+	path, err := e.write(data, block.MediaType)
+	if err != nil {
+		return block, err
+	}
+
+	block.Path = path
+	block.Data = ""
+	return block, nil
+}
+
+// write stores data under a content-addressed name and returns its path
+func (e *Extractor) write(data []byte, mediaType string) (string, error) {
+	sum := sha256.Sum256(data)
+	path := filepath.Join(e.dir, hex.EncodeToString(sum[:16])+extension(mediaType))
+
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create media directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write media file: %w", err)
+	}
+
+	return path, nil
+}
+
+// extension returns the file extension for a media type
+func extension(mediaType string) string {
+	if ext, ok := extensions[mediaType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
@@ -0,0 +1,92 @@
+package media
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/block/braindump/model"
+)
+
+func TestExtractorSession(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "media")
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+	encoded := base64.StdEncoding.EncodeToString(png)
+
+	session := model.Session{
+		SessionID: "session-1",
+		Messages: []model.Message{
+			{Role: "user", Content: []model.ContentBlock{
+				{Type: "text", Text: "See screenshot"},
+				{Type: "image", SourceType: "base64", MediaType: "image/png", Data: encoded},
+				{Type: "document", SourceType: "text", MediaType: "text/plain", Data: "inline notes"},
+				{Type: "image", SourceType: "url", URL: "https://example.com/a.png"},
+			}},
+			{Role: "user", Content: []model.ContentBlock{
+				{Type: "tool_result", ToolUseID: "tool-1", Parts: []model.ContentBlock{
+					{Type: "image", SourceType: "base64", MediaType: "image/png", Data: encoded},
+				}},
+			}},
+		},
+		Subagents: []model.Subagent{
+			{AgentID: "a1", Messages: []model.Message{
+				{Role: "user", Content: []model.ContentBlock{
+					{Type: "image", SourceType: "base64", MediaType: "image/png", Data: encoded},
+				}},
+			}},
+		},
+	}
+
+	extracted, err := NewExtractor(dir).Session(session)
+	if err != nil {
+		t.Fatalf("Session: %v", err)
+	}
+
+	image := extracted.Messages[0].Content[1]
+	if image.Data != "" || filepath.Ext(image.Path) != ".png" || filepath.Dir(image.Path) != dir {
+		t.Fatalf("image not extracted: %+v", image)
+	}
+	data, err := os.ReadFile(image.Path)
+	if err != nil || string(data) != string(png) {
+		t.Errorf("extracted file = %q, %v, want %q", data, err, png)
+	}
+
+	// The same attachment in a subagent maps to the same file
+	if sub := extracted.Subagents[0].Messages[0].Content[0]; sub.Path != image.Path {
+		t.Errorf("subagent image path = %q, want %q", sub.Path, image.Path)
+	}
+
+	// Media in tool results is extracted too
+	if part := extracted.Messages[1].Content[0].Parts[0]; part.Path != image.Path || part.Data != "" {
+		t.Errorf("tool result image not extracted: %+v", part)
+	}
+
+	// Text and URL sources are left as they are
+	if doc := extracted.Messages[0].Content[2]; doc.Data != "inline notes" || doc.Path != "" {
+		t.Errorf("text document changed: %+v", doc)
+	}
+	if remote := extracted.Messages[0].Content[3]; remote.Path != "" {
+		t.Errorf("url image changed: %+v", remote)
+	}
+
+	// The original session still has its inline data
+	if session.Messages[0].Content[1].Data != encoded || session.Messages[1].Content[0].Parts[0].Data != encoded {
+		t.Error("Session modified the original session")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected one extracted file, got %d (%v)", len(entries), err)
+	}
+}
+
+func TestNilExtractor(t *testing.T) {
+	var extractor *Extractor
+	session := model.Session{SessionID: "session-1"}
+
+	got, err := extractor.Session(session)
+	if err != nil || got.SessionID != "session-1" {
+		t.Errorf("nil extractor = %+v, %v", got, err)
+	}
+}
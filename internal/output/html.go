@@ -0,0 +1,152 @@
+package output
+
+import (
+	_ "embed" // Embed the HTML template
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/block/braindump/model"
+)
+
+//go:embed html.tmpl
+var htmlTemplateText string
+
+// htmlTemplate renders a complete, self-contained transcript viewer
+var htmlTemplate = template.Must(template.New("html").Funcs(template.FuncMap{
+	"json":         formatToolInput,
+	"time":         formatHTMLTime,
+	"roleLabel":    roleLabel,
+	"visibility":   visibility,
+	"location":     mediaLocation,
+	"compaction":   compactionLabel,
+	"dataURL":      dataURL,
+	"extensions":   extensionNames,
+	"eventLabel":   eventLabel,
+	"eventSummary": eventSummary,
+}).Parse(htmlTemplateText))
+
+// HTMLWriter handles writing sessions as a single static HTML page
+type HTMLWriter struct {
+	writer io.Writer
+}
+
+// htmlPage is the data passed to the HTML template
+type htmlPage struct {
+	GeneratedAt time.Time
+	Sessions    []htmlSession
+}
+
+// htmlSession is a session prepared for rendering
+type htmlSession struct {
+	Anchor   string
+	Session  model.Session
+	Main     htmlThread
+	Threads  []htmlThread
+	Messages int
+}
+
+// htmlThread is a list of messages rendered together, either a session's
+// main conversation or one subagent's conversation
+type htmlThread struct {
+	Anchor    string
+	Session   string // anchor of the owning session, used to scope element IDs
+	Title     string
+	ToolUseID string
+	Messages  []model.Message
+	Events    []model.Event
+	// Subagents maps Task tool_use IDs to the threads they spawned
+	Subagents map[string][]htmlThread
+}
+
+// NewHTMLWriter creates a new HTML writer
+func NewHTMLWriter(w io.Writer) *HTMLWriter {
+	return &HTMLWriter{writer: w}
+}
+
+// Write writes sessions to output as a single HTML document with embedded CSS and JS
+func (w *HTMLWriter) Write(sessions []model.Session) error {
+	page := htmlPage{GeneratedAt: time.Now()}
+
+	for i, session := range sessions {
+		anchor := fmt.Sprintf("s%d", i)
+
+		view := htmlSession{
+			Anchor:   anchor,
+			Session:  session,
+			Messages: len(session.Messages),
+			Main: htmlThread{
+				Anchor:    anchor,
+				Session:   anchor,
+				Messages:  session.Messages,
+				Events:    session.Events,
+				Subagents: make(map[string][]htmlThread),
+			},
+		}
+
+		for j, subagent := range session.Subagents {
+			thread := htmlThread{
+				Anchor:    fmt.Sprintf("%s-a%d", anchor, j),
+				Session:   anchor,
+				Title:     subagentTitle(subagent),
+				ToolUseID: subagent.ToolUseID,
+				Messages:  subagent.Messages,
+				Events:    subagent.Events,
+			}
+			view.Threads = append(view.Threads, thread)
+			if subagent.ToolUseID != "" {
+				view.Main.Subagents[subagent.ToolUseID] = append(view.Main.Subagents[subagent.ToolUseID], thread)
+			}
+		}
+
+		page.Sessions = append(page.Sessions, view)
+	}
+
+	return htmlTemplate.Execute(w.writer, page)
+}
+
+// subagentTitle returns a display name for a subagent
+func subagentTitle(subagent model.Subagent) string {
+	if subagent.Slug != "" {
+		return subagent.Slug + " (" + subagent.AgentID + ")"
+	}
+	return subagent.AgentID
+}
+
+// formatToolInput renders tool input as indented JSON
+func formatToolInput(input map[string]any) string {
+	data, err := json.MarshalIndent(input, "", "  ")
+	if err != nil {
+		return fmt.Sprint(input)
+	}
+	return string(data)
+}
+
+// dataURL returns a data URL embedding an inline base64 image, or "" for
+// other blocks. html/template rejects data URLs unless they are marked safe.
+func dataURL(block model.ContentBlock) template.URL {
+	if block.Type != "image" || block.SourceType != "base64" || block.Data == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(block.MediaType)
+	if err != nil || !strings.HasPrefix(mediaType, "image/") {
+		return ""
+	}
+	if _, err := base64.StdEncoding.DecodeString(block.Data); err != nil {
+		return ""
+	}
+	return template.URL("data:" + mediaType + ";base64," + block.Data) //nolint:gosec // a validated media type and base64 cannot break out of the URL
+}
+
+// formatHTMLTime formats a timestamp for display, or returns "" for the zero time
+func formatHTMLTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
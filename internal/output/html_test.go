@@ -0,0 +1,103 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/block/braindump/model"
+)
+
+func TestHTMLWriter(t *testing.T) {
+	session := model.Session{
+		AgentType: "claude",
+		SessionID: "session-1",
+		Messages: []model.Message{
+			{
+				Role:    "user",
+				Content: []model.ContentBlock{{Type: "text", Text: "<script>alert(1)</script>"}},
+			},
+			{
+				Role: "assistant",
+				Content: []model.ContentBlock{
+					{Type: "tool_use", ToolName: "Task", ToolUseID: "tool-1", ToolInput: map[string]any{"prompt": "Find it"}},
+				},
+				Metadata: model.MessageMetadata{Tokens: &model.TokenUsage{InputTokens: 12, OutputTokens: 34}},
+			},
+		},
+		Subagents: []model.Subagent{{
+			AgentID:   "abc123",
+			ToolUseID: "tool-1",
+			Events:    []model.Event{{Kind: model.EventProgress, Subtype: "hook_progress"}},
+		}},
+		Events: []model.Event{
+			{Kind: model.EventSystem, Subtype: "api_error", Level: "error", Timestamp: time.Date(2026, 2, 7, 12, 0, 5, 0, time.UTC), Text: "Overloaded\nretrying"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := NewHTMLWriter(&buf).Write([]model.Session{session}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`<section class="session" id="s0">`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		"in 12 · out 34",
+		`<details id="s0-tool-tool-1">`,
+		`<a href="#s0-a0">abc123</a>`,
+		`<div class="thread" id="s0-a0">`,
+		`<a href="#s0-tool-tool-1">`,
+		`<span class="meta">2026-02-07 12:00:05</span> <code>system/api_error</code> <strong class="level error">error</strong>: Overloaded</li>`,
+		"<code>progress/hook_progress</code></li>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output missing %q", want)
+		}
+	}
+
+	if strings.Contains(out, "<script>alert(1)") {
+		t.Error("Message text was not escaped")
+	}
+}
+
+func TestHTMLWriterMedia(t *testing.T) {
+	session := model.Session{
+		AgentType: "claude",
+		SessionID: "session-1",
+		Messages: []model.Message{
+			{
+				Role: "user",
+				Content: []model.ContentBlock{
+					{Type: "image", SourceType: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="},
+					{Type: "image", SourceType: "base64", MediaType: "image/png", Path: "media/abc.png"},
+					{Type: "image", SourceType: "url", URL: "javascript:alert(1)"},
+					{Type: "document", SourceType: "text", Title: "notes.txt", Data: "plain notes"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := NewHTMLWriter(&buf).Write([]model.Session{session}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`src="data:image/png;base64,iVBORw0KGgo="`,
+		`src="media/abc.png"`,
+		"<summary>📎 notes.txt</summary>",
+		"plain notes",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output missing %q", want)
+		}
+	}
+
+	if strings.Contains(out, "javascript:alert") {
+		t.Error("Unsafe media URL was not sanitized")
+	}
+}
@@ -0,0 +1,370 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/block/braindump/model"
+)
+
+// MarkdownWriter handles writing full conversation transcripts as Markdown
+type MarkdownWriter struct {
+	writer io.Writer
+}
+
+// NewMarkdownWriter creates a new Markdown writer
+func NewMarkdownWriter(w io.Writer) *MarkdownWriter {
+	return &MarkdownWriter{writer: w}
+}
+
+// Write writes session transcripts to output
+func (w *MarkdownWriter) Write(sessions []model.Session) error {
+	if len(sessions) == 0 {
+		_, err := fmt.Fprintln(w.writer, "_No sessions found._")
+		return err
+	}
+
+	for i, session := range sessions {
+		var b strings.Builder
+		if i > 0 {
+			b.WriteString("\n---\n\n")
+		}
+		writeSessionMarkdown(&b, session)
+
+		if _, err := io.WriteString(w.writer, b.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeSessionMarkdown renders a single session
+func writeSessionMarkdown(b *strings.Builder, session model.Session) {
+	title := session.SessionID
+	if session.Metadata.Name != "" {
+		title = session.Metadata.Name + " (" + session.SessionID + ")"
+	}
+	fmt.Fprintf(b, "# Session %s\n\n", title)
+
+	fmt.Fprintf(b, "- **Agent:** %s\n", session.AgentType)
+	fmt.Fprintf(b, "- **Created:** %s\n", session.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(b, "- **Updated:** %s\n", session.UpdatedAt.Format("2006-01-02 15:04:05"))
+	if session.Metadata.WorkingDir != "" {
+		fmt.Fprintf(b, "- **Working Dir:** `%s`\n", session.Metadata.WorkingDir)
+	}
+	if session.Metadata.GitBranch != "" {
+		fmt.Fprintf(b, "- **Git Branch:** `%s`\n", session.Metadata.GitBranch)
+	}
+	if session.Metadata.Model != "" {
+		fmt.Fprintf(b, "- **Model:** %s\n", session.Metadata.Model)
+	}
+	if names := extensionNames(session.Metadata.Extensions); names != "" {
+		fmt.Fprintf(b, "- **Extensions:** %s\n", names)
+	}
+	if recipe := session.Metadata.Recipe; recipe != nil && recipe.Title != "" {
+		fmt.Fprintf(b, "- **Recipe:** %s\n", recipe.Title)
+	}
+	if session.Metadata.ScheduleID != "" {
+		fmt.Fprintf(b, "- **Schedule:** `%s`\n", session.Metadata.ScheduleID)
+	}
+	b.WriteString("\n")
+
+	// Subagents are rendered under the Task tool_use that spawned them
+	subagentsByToolUse := make(map[string][]model.Subagent)
+	var unlinked []model.Subagent
+	for _, subagent := range session.Subagents {
+		if subagent.ToolUseID != "" {
+			subagentsByToolUse[subagent.ToolUseID] = append(subagentsByToolUse[subagent.ToolUseID], subagent)
+		} else {
+			unlinked = append(unlinked, subagent)
+		}
+	}
+
+	writeMessagesMarkdown(b, session.Messages, 1, subagentsByToolUse)
+
+	if len(unlinked) > 0 {
+		b.WriteString("## Subagents\n\n")
+		for _, subagent := range unlinked {
+			writeSubagentMarkdown(b, subagent, 2)
+		}
+	}
+
+	writeEventsMarkdown(b, session.Events, 1)
+}
+
+// writeEventsMarkdown renders events as a list under a heading one level below level
+func writeEventsMarkdown(b *strings.Builder, events []model.Event, level int) {
+	if len(events) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "%s Events\n\n", strings.Repeat("#", level+1))
+	for _, event := range events {
+		b.WriteString("- ")
+		if !event.Timestamp.IsZero() {
+			fmt.Fprintf(b, "%s · ", event.Timestamp.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Fprintf(b, "`%s`", eventLabel(event))
+		if event.Level != "" && event.Level != "info" {
+			fmt.Fprintf(b, " **%s**", event.Level)
+		}
+		if text := eventSummary(event); text != "" {
+			fmt.Fprintf(b, ": %s", text)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+// eventLabel names an event by its kind and subtype, e.g. "system/api_error"
+func eventLabel(event model.Event) string {
+	if event.Subtype == "" {
+		return event.Kind
+	}
+	return event.Kind + "/" + event.Subtype
+}
+
+// eventSummary returns the first line of an event's text
+func eventSummary(event model.Event) string {
+	text, _, _ := strings.Cut(strings.TrimSpace(event.Text), "\n")
+	return text
+}
+
+// extensionNames lists the names of a session's extensions, separated by commas
+func extensionNames(extensions []model.Extension) string {
+	names := make([]string, len(extensions))
+	for i, extension := range extensions {
+		names[i] = extension.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// writeMessagesMarkdown renders messages as turns with headings one level below level
+func writeMessagesMarkdown(b *strings.Builder, messages []model.Message, level int, subagents map[string][]model.Subagent) {
+	for _, msg := range messages {
+		fmt.Fprintf(b, "%s %s", strings.Repeat("#", level+1), roleLabel(msg))
+		if note := visibility(msg); note != "" {
+			fmt.Fprintf(b, " _(%s)_", note)
+		}
+		if !msg.Timestamp.IsZero() {
+			fmt.Fprintf(b, " · %s", msg.Timestamp.Format("2006-01-02 15:04:05"))
+		}
+		b.WriteString("\n\n")
+
+		for _, block := range msg.Content {
+			writeBlockMarkdown(b, block)
+
+			if block.Type == "tool_use" {
+				for _, subagent := range subagents[block.ToolUseID] {
+					writeSubagentMarkdown(b, subagent, level+1)
+				}
+			}
+		}
+	}
+}
+
+// writeSubagentMarkdown renders a subagent transcript in a collapsible section
+func writeSubagentMarkdown(b *strings.Builder, subagent model.Subagent, level int) {
+	name := subagent.AgentID
+	if subagent.Slug != "" {
+		name = subagent.Slug + " (" + subagent.AgentID + ")"
+	}
+
+	fmt.Fprintf(b, "<details>\n<summary>Subagent %s · %d message(s)</summary>\n\n", name, len(subagent.Messages))
+	writeMessagesMarkdown(b, subagent.Messages, level, nil)
+	writeEventsMarkdown(b, subagent.Events, level)
+	b.WriteString("</details>\n\n")
+}
+
+// writeBlockMarkdown renders a single content block
+func writeBlockMarkdown(b *strings.Builder, block model.ContentBlock) {
+	switch block.Type {
+	case "text":
+		if block.Text != "" {
+			b.WriteString(block.Text)
+			b.WriteString("\n\n")
+		}
+
+	case "thinking":
+		if block.Thinking != "" {
+			b.WriteString("<details>\n<summary>💭 Thinking</summary>\n\n")
+			b.WriteString(block.Thinking)
+			b.WriteString("\n\n</details>\n\n")
+		}
+
+	case "redacted_thinking":
+		b.WriteString("_💭 Thinking redacted_\n\n")
+
+	case "compact_boundary":
+		fmt.Fprintf(b, "_🗜️ %s_\n\n", compactionLabel(block))
+
+	case "image", "document":
+		writeMediaMarkdown(b, block)
+
+	case "tool_use":
+		fmt.Fprintf(b, "**Tool call:** `%s`", block.ToolName)
+		if block.ToolUseID != "" {
+			fmt.Fprintf(b, " (`%s`)", block.ToolUseID)
+		}
+		b.WriteString("\n\n")
+
+		if len(block.ToolInput) > 0 {
+			input, err := json.MarshalIndent(block.ToolInput, "", "  ")
+			if err != nil {
+				input = []byte(fmt.Sprint(block.ToolInput))
+			}
+			writeFenced(b, "json", string(input))
+		}
+
+	case "tool_result":
+		summary := "Tool result"
+		if block.IsError {
+			summary = "❌ Tool error"
+		}
+		if block.ToolUseID != "" {
+			summary += " (" + block.ToolUseID + ")"
+		}
+		fmt.Fprintf(b, "<details>\n<summary>%s</summary>\n\n", summary)
+		writeFenced(b, "", block.ToolContent)
+		// Text parts are already included in the tool content
+		for _, part := range block.Parts {
+			if part.Type != "text" {
+				writeBlockMarkdown(b, part)
+			}
+		}
+		b.WriteString("</details>\n\n")
+
+	default:
+		if block.Text != "" {
+			fmt.Fprintf(b, "_%s:_\n\n%s\n\n", block.Type, block.Text)
+		}
+	}
+}
+
+// writeMediaMarkdown renders an image or document, linking to it when it has a location
+func writeMediaMarkdown(b *strings.Builder, block model.ContentBlock) {
+	label := block.Type
+	if block.Title != "" {
+		label = block.Title
+	}
+	description := label
+	if block.MediaType != "" {
+		description += " (" + block.MediaType + ")"
+	}
+
+	location := mediaLocation(block)
+	switch {
+	case location != "" && block.Type == "image":
+		fmt.Fprintf(b, "![%s](<%s>)\n\n", label, location)
+	case location != "":
+		fmt.Fprintf(b, "📎 [%s](<%s>)\n\n", description, location)
+	case block.SourceType == "text" && block.Data != "":
+		fmt.Fprintf(b, "<details>\n<summary>📎 %s</summary>\n\n", description)
+		writeFenced(b, "", block.Data)
+		b.WriteString("</details>\n\n")
+	default:
+		fmt.Fprintf(b, "_📎 %s_\n\n", description)
+	}
+}
+
+// compactionLabel describes a compact_boundary block, e.g.
+// "Conversation compacted (auto, 155000 tokens before)"
+func compactionLabel(block model.ContentBlock) string {
+	label := block.Text
+	if label == "" {
+		label = "Conversation compacted"
+	}
+
+	var details []string
+	if c := block.Compaction; c != nil {
+		if c.Trigger != "" {
+			details = append(details, c.Trigger)
+		}
+		if c.PreTokens > 0 {
+			details = append(details, fmt.Sprintf("%d tokens before", c.PreTokens))
+		}
+	}
+	if len(details) > 0 {
+		label += " (" + strings.Join(details, ", ") + ")"
+	}
+	return label
+}
+
+// mediaLocation returns where an image or document can be opened from,
+// preferring an extracted file over a remote URL
+func mediaLocation(block model.ContentBlock) string {
+	if block.Path != "" {
+		return block.Path
+	}
+	return block.URL
+}
+
+// writeFenced writes content as a fenced code block, using a fence longer
+// than any run of backticks in the content so it cannot be closed early
+func writeFenced(b *strings.Builder, lang, content string) {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+
+	b.WriteString(fence + lang + "\n")
+	b.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(fence + "\n\n")
+}
+
+// roleLabel returns the heading label for a message
+func roleLabel(msg model.Message) string {
+	switch msg.Role {
+	case "user":
+		if isToolResultOnly(msg) {
+			return "🔧 Tool Result"
+		}
+		return "👤 User"
+	case "assistant":
+		return "🤖 Assistant"
+	case "system":
+		return "⚙️ System"
+	default:
+		return msg.Role
+	}
+}
+
+// visibility describes who a message was hidden from, or returns "" for
+// messages both the user and the agent saw
+func visibility(msg model.Message) string {
+	switch {
+	case !msg.Metadata.VisibleToUser() && !msg.Metadata.VisibleToAgent():
+		return "hidden"
+	case !msg.Metadata.VisibleToUser():
+		return "agent only"
+	case !msg.Metadata.VisibleToAgent():
+		return "user only"
+	default:
+		return ""
+	}
+}
+
+// isToolResultOnly reports whether a message carries nothing but tool results
+func isToolResultOnly(msg model.Message) bool {
+	if len(msg.Content) == 0 {
+		return false
+	}
+	for _, block := range msg.Content {
+		if block.Type != "tool_result" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/block/braindump/model"
+)
+
+func TestMarkdownWriter(t *testing.T) {
+	session := model.Session{
+		AgentType: "claude",
+		SessionID: "session-1",
+		CreatedAt: time.Date(2026, 2, 7, 12, 0, 0, 0, time.UTC),
+		Metadata: model.SessionMetadata{
+			Extensions: []model.Extension{{Name: "developer", Type: "builtin"}, {Name: "github", Type: "stdio"}},
+			Recipe:     &model.Recipe{Title: "Triage"},
+		},
+		Messages: []model.Message{
+			{
+				Role: "user",
+				Content: []model.ContentBlock{
+					{Type: "text", Text: "Fix the migration"},
+					{Type: "image", SourceType: "base64", MediaType: "image/png", Path: "media/abc.png"},
+					{Type: "document", SourceType: "file", MediaType: "application/pdf", FileID: "file_1", Title: "spec.pdf"},
+				},
+			},
+			{
+				Role: "assistant",
+				Content: []model.ContentBlock{
+					{Type: "thinking", Thinking: "Delegate the search"},
+					{Type: "redacted_thinking", Data: "xyz"},
+					{Type: "tool_use", ToolName: "Task", ToolUseID: "tool-1", ToolInput: map[string]any{"prompt": "Find migrations"}},
+				},
+			},
+			{
+				Role: "system",
+				Content: []model.ContentBlock{
+					{Type: "compact_boundary", Text: "Conversation compacted", Compaction: &model.Compaction{Trigger: "auto", PreTokens: 155000}},
+				},
+			},
+			{
+				Role: "user",
+				Content: []model.ContentBlock{
+					{Type: "tool_result", ToolUseID: "tool-1", ToolContent: "uses ``` fences"},
+					{Type: "tool_result", ToolUseID: "tool-2", ToolContent: "not found", IsError: true},
+				},
+			},
+		},
+		Subagents: []model.Subagent{
+			{
+				AgentID:   "abc123",
+				Slug:      "happy-cat",
+				ToolUseID: "tool-1",
+				Messages: []model.Message{
+					{Role: "user", Content: []model.ContentBlock{{Type: "text", Text: "Find migrations"}}},
+				},
+				Events: []model.Event{{Kind: model.EventSystem, Subtype: "api_error", Level: "error", Text: "Rate limited"}},
+			},
+		},
+		Events: []model.Event{
+			{Kind: model.EventSystem, Subtype: "api_error", Level: "error", Timestamp: time.Date(2026, 2, 7, 12, 0, 5, 0, time.UTC), Text: "Overloaded\nretrying"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := NewMarkdownWriter(&buf).Write([]model.Session{session}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# Session session-1",
+		"- **Extensions:** developer, github",
+		"- **Recipe:** Triage",
+		"## 👤 User",
+		"Fix the migration",
+		"![image](<media/abc.png>)",
+		"_📎 spec.pdf (application/pdf)_",
+		"<summary>💭 Thinking</summary>\n\nDelegate the search\n\n</details>",
+		"_💭 Thinking redacted_",
+		"**Tool call:** `Task` (`tool-1`)",
+		"```json\n{\n  \"prompt\": \"Find migrations\"\n}\n```",
+		"<summary>Subagent happy-cat (abc123) · 1 message(s)</summary>",
+		"### 👤 User",
+		"### Events\n\n- `system/api_error` **error**: Rate limited\n",
+		"## 🔧 Tool Result",
+		"````\nuses ``` fences\n````",
+		"<summary>❌ Tool error (tool-2)</summary>",
+		"## ⚙️ System",
+		"_🗜️ Conversation compacted (auto, 155000 tokens before)_",
+		"## Events\n\n- 2026-02-07 12:00:05 · `system/api_error` **error**: Overloaded\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output missing %q\n%s", want, out)
+		}
+	}
+
+	// The subagent is nested under the tool call, before the tool result
+	if strings.Index(out, "Subagent happy-cat") > strings.Index(out, "Tool Result") {
+		t.Error("Subagent should be rendered under its Task tool call")
+	}
+}
@@ -0,0 +1,24 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/block/braindump/model"
+)
+
+// NDJSONWriter writes sessions as newline-delimited JSON, one session per line.
+// Each session is written as soon as it is received so memory use stays constant.
+type NDJSONWriter struct {
+	encoder *json.Encoder
+}
+
+// NewNDJSONWriter creates a new NDJSON writer
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{encoder: json.NewEncoder(w)}
+}
+
+// WriteSession writes a single session as one JSON line
+func (w *NDJSONWriter) WriteSession(session model.Session) error {
+	return w.encoder.Encode(session)
+}
@@ -5,7 +5,7 @@ import (
 	"io"
 	"strings"
 
-	"github.com/block/braindump/internal/model"
+	"github.com/block/braindump/model"
 )
 
 // SummaryWriter handles writing human-readable summaries
@@ -150,35 +150,54 @@ func (w *SummaryWriter) writeSessionSummary(session model.Session) error {
 		}
 	}
 
+	if len(session.Events) > 0 {
+		_, err = fmt.Fprintf(w.writer, "   Events: %d (Errors: %d)\n", len(session.Events), countErrorEvents(session.Events))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// findFirstUserMessage finds the first user message in the session
+// countErrorEvents counts the events recorded at error level
+func countErrorEvents(events []model.Event) int {
+	count := 0
+	for _, event := range events {
+		if event.Level == "error" {
+			count++
+		}
+	}
+	return count
+}
+
+// findFirstUserMessage finds the first user message in the session,
+// skipping messages the user never saw, such as injected context
 func findFirstUserMessage(messages []model.Message) *model.Message {
 	for i := range messages {
-		if messages[i].Role == "user" {
+		if messages[i].Role == "user" && messages[i].Metadata.VisibleToUser() {
 			return &messages[i]
 		}
 	}
 	return nil
 }
 
-// findLastUserMessage finds the last user message in the session
+// findLastUserMessage finds the last user message in the session that the user saw
 func findLastUserMessage(messages []model.Message) *model.Message {
 	for i := len(messages) - 1; i >= 0; i-- {
-		if messages[i].Role == "user" {
+		if messages[i].Role == "user" && messages[i].Metadata.VisibleToUser() {
 			return &messages[i]
 		}
 	}
 	return nil
 }
 
-// findLastAgentMessages finds the last N assistant messages
+// findLastAgentMessages finds the last N assistant messages that the user saw
 func findLastAgentMessages(messages []model.Message, count int) []model.Message {
 	var result []model.Message
 
 	for i := len(messages) - 1; i >= 0 && len(result) < count; i-- {
-		if messages[i].Role == "assistant" {
+		if messages[i].Role == "assistant" && messages[i].Metadata.VisibleToUser() {
 			result = append([]model.Message{messages[i]}, result...)
 		}
 	}
@@ -0,0 +1,38 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/block/braindump/model"
+)
+
+func TestSummaryWriterSkipsHiddenMessages(t *testing.T) {
+	hidden := false
+	session := model.Session{
+		AgentType: "goose",
+		SessionID: "session-1",
+		Messages: []model.Message{
+			{UUID: "m1", Role: "user", Metadata: model.MessageMetadata{UserVisible: &hidden},
+				Content: []model.ContentBlock{{Type: "text", Text: "<info>injected context</info>"}}},
+			{UUID: "m2", Role: "user", Content: []model.ContentBlock{{Type: "text", Text: "Fix the build"}}},
+			{UUID: "m3", Role: "assistant", Content: []model.ContentBlock{{Type: "text", Text: "Fixed"}}},
+			{UUID: "m4", Role: "user", Metadata: model.MessageMetadata{UserVisible: &hidden},
+				Content: []model.ContentBlock{{Type: "text", Text: "<info>more context</info>"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := NewSummaryWriter(&buf).Write([]model.Session{session}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, "Initial User Prompt:\n   Fix the build") {
+		t.Errorf("expected the first visible prompt as the initial prompt\n%s", out)
+	}
+	if strings.Contains(out, "context") || strings.Contains(out, "Last User Prompt") {
+		t.Errorf("hidden messages shown as user prompts\n%s", out)
+	}
+}
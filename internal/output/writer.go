@@ -5,7 +5,7 @@ import (
 	"io"
 	"time"
 
-	"github.com/block/braindump/internal/model"
+	"github.com/block/braindump/model"
 )
 
 const Version = "1.0.0"
@@ -24,12 +24,13 @@ func NewWriter(w io.Writer, pretty bool) *Writer {
 	}
 }
 
-// Write writes sessions to output
-func (w *Writer) Write(sessions []model.Session) error {
+// Write writes sessions to output, along with any problems found reading them
+func (w *Writer) Write(sessions []model.Session, diagnostics []model.Diagnostic) error {
 	output := model.Output{
 		Version:     Version,
 		GeneratedAt: time.Now(),
 		Sessions:    sessions,
+		Diagnostics: diagnostics,
 	}
 
 	var data []byte
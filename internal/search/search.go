@@ -0,0 +1,225 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/block/braindump/model"
+)
+
+// Scopes name the parts of a message that can be searched
+const (
+	ScopeText       = "text"
+	ScopeThinking   = "thinking"
+	ScopeToolInput  = "tool_input"
+	ScopeToolResult = "tool_result"
+)
+
+// Scopes lists every searchable scope
+var Scopes = []string{ScopeText, ScopeThinking, ScopeToolInput, ScopeToolResult}
+
+// DefaultContext is the number of characters shown either side of a match
+const DefaultContext = 60
+
+// Options contains search options
+type Options struct {
+	Pattern *regexp.Regexp
+	// In restricts the search to the given scopes. Empty searches all scopes.
+	In []string
+	// Context is the number of characters of context shown either side of a match
+	Context int
+}
+
+// Hit is a run of one or more nearby matches within a single content block
+type Hit struct {
+	AgentType string
+	SessionID string
+	AgentID   string // subagent ID, when the match is in a subagent conversation
+	Timestamp time.Time
+	Role      string
+	Scope     string
+	ToolName  string
+	// Snippet is the matched text with surrounding context, on a single line
+	Snippet string
+	// Matches are the [start, end) byte offsets of each match within Snippet
+	Matches [][2]int
+}
+
+// Compile builds the search pattern. Unless regex is set, the query is matched literally.
+func Compile(query string, regex, ignoreCase bool) (*regexp.Regexp, error) {
+	if !regex {
+		query = regexp.QuoteMeta(query)
+	}
+	if ignoreCase {
+		query = "(?i)" + query
+	}
+
+	pattern, err := regexp.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return pattern, nil
+}
+
+// ValidateScopes checks that every scope is known
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeText, ScopeThinking, ScopeToolInput, ScopeToolResult:
+		default:
+			return fmt.Errorf("unknown scope %q (expected %s)", scope, strings.Join(Scopes, ", "))
+		}
+	}
+	return nil
+}
+
+// Session searches a session, including its subagents, and returns the hits in message order
+func Session(session model.Session, opts Options) []Hit {
+	var hits []Hit
+
+	hits = searchMessages(hits, session, "", session.Messages, opts)
+	for _, subagent := range session.Subagents {
+		hits = searchMessages(hits, session, subagent.AgentID, subagent.Messages, opts)
+	}
+
+	return hits
+}
+
+// searchMessages appends the hits found in messages
+func searchMessages(hits []Hit, session model.Session, agentID string, messages []model.Message, opts Options) []Hit {
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			scope, text := blockText(block)
+			if text == "" || !inScope(scope, opts.In) {
+				continue
+			}
+
+			for _, snippet := range snippets(text, opts.Pattern, opts.Context) {
+				snippet.AgentType = session.AgentType
+				snippet.SessionID = session.SessionID
+				snippet.AgentID = agentID
+				snippet.Timestamp = msg.Timestamp
+				snippet.Role = msg.Role
+				snippet.Scope = scope
+				snippet.ToolName = block.ToolName
+				hits = append(hits, snippet)
+			}
+		}
+	}
+
+	return hits
+}
+
+// blockText returns the scope of a content block and its searchable text
+func blockText(block model.ContentBlock) (string, string) {
+	switch block.Type {
+	case "thinking":
+		return ScopeThinking, block.Thinking
+
+	case "redacted_thinking":
+		return ScopeThinking, ""
+
+	case "tool_use":
+		if len(block.ToolInput) == 0 {
+			return ScopeToolInput, ""
+		}
+		input, err := json.Marshal(block.ToolInput)
+		if err != nil {
+			return ScopeToolInput, ""
+		}
+		return ScopeToolInput, string(input)
+
+	case "tool_result":
+		return ScopeToolResult, block.ToolContent
+
+	default:
+		return ScopeText, block.Text
+	}
+}
+
+// inScope reports whether scope is one of the requested scopes
+func inScope(scope string, in []string) bool {
+	if len(in) == 0 {
+		return true
+	}
+	for _, s := range in {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// snippets finds the matches in text and groups matches whose context
+// windows overlap into a single hit
+func snippets(text string, pattern *regexp.Regexp, context int) []Hit {
+	matches := pattern.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var hits []Hit
+
+	for i := 0; i < len(matches); {
+		start := backRunes(text, matches[i][0], context)
+		end := forwardRunes(text, matches[i][1], context)
+
+		group := [][2]int{{matches[i][0], matches[i][1]}}
+		i++
+		for i < len(matches) && matches[i][0] < end {
+			group = append(group, [2]int{matches[i][0], matches[i][1]})
+			end = forwardRunes(text, matches[i][1], context)
+			i++
+		}
+
+		snippet := text[start:end]
+		prefix := ""
+		if start > 0 {
+			prefix = "…"
+		}
+
+		hit := Hit{Snippet: prefix + flatten(snippet)}
+		if end < len(text) {
+			hit.Snippet += "…"
+		}
+		for _, m := range group {
+			hit.Matches = append(hit.Matches, [2]int{m[0] - start + len(prefix), m[1] - start + len(prefix)})
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits
+}
+
+// backRunes returns the byte offset n runes before offset
+func backRunes(text string, offset, n int) int {
+	for ; n > 0 && offset > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(text[:offset])
+		offset -= size
+	}
+	return offset
+}
+
+// forwardRunes returns the byte offset n runes after offset
+func forwardRunes(text string, offset, n int) int {
+	for ; n > 0 && offset < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// flatten replaces line breaks and tabs with spaces, preserving byte offsets
+func flatten(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\n', '\r', '\t':
+			return ' '
+		}
+		return r
+	}, s)
+}
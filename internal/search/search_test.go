@@ -0,0 +1,138 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/block/braindump/model"
+)
+
+func testSession() model.Session {
+	return model.Session{
+		AgentType: "claude",
+		SessionID: "session-1",
+		Messages: []model.Message{
+			{
+				Role:    "user",
+				Content: []model.ContentBlock{{Type: "text", Text: "Please fix the Migration in db/migrate"}},
+			},
+			{
+				Role: "assistant",
+				Content: []model.ContentBlock{
+					{Type: "tool_use", ToolName: "Bash", ToolInput: map[string]any{"command": "rake db:migrate"}},
+				},
+			},
+			{
+				Role:    "user",
+				Content: []model.ContentBlock{{Type: "tool_result", ToolContent: "migrated 3 tables\nmigration complete"}},
+			},
+		},
+		Subagents: []model.Subagent{
+			{
+				AgentID: "abc123",
+				Messages: []model.Message{
+					{Role: "assistant", Content: []model.ContentBlock{{Type: "text", Text: "The migration is fine"}}},
+				},
+			},
+		},
+	}
+}
+
+func TestSession(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		regex      bool
+		ignoreCase bool
+		in         []string
+		expected   int
+	}{
+		{
+			name:     "literal case-sensitive",
+			query:    "migration",
+			expected: 2,
+		},
+		{
+			name:       "case-insensitive",
+			query:      "migration",
+			ignoreCase: true,
+			expected:   3,
+		},
+		{
+			name:     "regex",
+			query:    `migrat(e|ed)\b`,
+			regex:    true,
+			expected: 3,
+		},
+		{
+			name:     "literal does not interpret regex",
+			query:    "db:migrat.",
+			expected: 0,
+		},
+		{
+			name:     "scoped to tool input",
+			query:    "migrate",
+			in:       []string{ScopeToolInput},
+			expected: 1,
+		},
+		{
+			name:     "scoped to text and tool result",
+			query:    "migrat",
+			in:       []string{ScopeText, ScopeToolResult},
+			expected: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := Compile(tt.query, tt.regex, tt.ignoreCase)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+
+			hits := Session(testSession(), Options{Pattern: pattern, In: tt.in, Context: 10})
+			if len(hits) != tt.expected {
+				t.Errorf("Expected %d hits, got %d: %+v", tt.expected, len(hits), hits)
+			}
+		})
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	pattern, err := Compile("needle", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := "a long haystack with a needle\nand another needle, then a lot of unrelated trailing text here"
+	hits := snippets(text, pattern, 15)
+
+	// Both matches are within each other's context, so they form one hit
+	if len(hits) != 1 {
+		t.Fatalf("Expected 1 hit, got %d", len(hits))
+	}
+
+	hit := hits[0]
+	if len(hit.Matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %d", len(hit.Matches))
+	}
+
+	for _, m := range hit.Matches {
+		if got := hit.Snippet[m[0]:m[1]]; got != "needle" {
+			t.Errorf("Match offsets select %q, want %q", got, "needle")
+		}
+	}
+
+	if want := "…aystack with a needle and another needle, then a lot of…"; hit.Snippet != want {
+		t.Errorf("Snippet: got %q, want %q", hit.Snippet, want)
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	if err := ValidateScopes([]string{ScopeText, ScopeToolResult}); err != nil {
+		t.Errorf("ValidateScopes: unexpected error %v", err)
+	}
+
+	if err := ValidateScopes([]string{"bogus"}); err == nil {
+		t.Error("ValidateScopes: expected error for unknown scope")
+	}
+}
@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/block/braindump/model"
+)
+
+// PriceTable holds per-model token prices used to estimate cost
+type PriceTable struct {
+	// Currency is a display label for costs (default "USD")
+	Currency string `json:"currency,omitempty"`
+	// Models maps a model name, or a prefix of one, to its prices
+	Models map[string]ModelPrice `json:"models"`
+}
+
+// Default prompt-cache prices, as multiples of the input price, used when a
+// model's cache prices are not given
+const (
+	defaultCacheWriteMultiplier = 1.25
+	defaultCacheReadMultiplier  = 0.1
+)
+
+// ModelPrice is the price of a model's tokens, per million tokens
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+	// CacheWrite and CacheRead price prompt-cache tokens. When zero they
+	// default to 1.25x and 0.1x the input price.
+	CacheWrite float64 `json:"cache_write,omitempty"`
+	CacheRead  float64 `json:"cache_read,omitempty"`
+}
+
+// DefaultPricesPath returns the price table loaded when none is given:
+// braindump/prices.json under the user config directory (e.g. ~/.config).
+func DefaultPricesPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "braindump", "prices.json"), nil
+}
+
+// LoadPrices reads a JSON price table from path
+func LoadPrices(path string) (*PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	var prices PriceTable
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, fmt.Errorf("failed to parse price table %s: %w", path, err)
+	}
+
+	if prices.Currency == "" {
+		prices.Currency = "USD"
+	}
+	return &prices, nil
+}
+
+// Lookup returns the price for a model. An exact match wins; otherwise the
+// longest entry that is a prefix of the model name is used, so
+// "claude-sonnet-4-5" prices "claude-sonnet-4-5-20250929".
+func (p *PriceTable) Lookup(model string) (ModelPrice, bool) {
+	if p == nil || model == "" {
+		return ModelPrice{}, false
+	}
+
+	if price, ok := p.Models[model]; ok {
+		return price, true
+	}
+
+	var best string
+	for name := range p.Models {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return p.Models[best], true
+}
+
+// cost returns the cost of a response's tokens
+func (m ModelPrice) cost(tokens model.TokenUsage) float64 {
+	cacheWrite := m.CacheWrite
+	if cacheWrite == 0 {
+		cacheWrite = m.Input * defaultCacheWriteMultiplier
+	}
+	cacheRead := m.CacheRead
+	if cacheRead == 0 {
+		cacheRead = m.Input * defaultCacheReadMultiplier
+	}
+
+	return (float64(tokens.InputTokens)*m.Input +
+		float64(tokens.CacheCreationInputTokens)*cacheWrite +
+		float64(tokens.CacheReadInputTokens)*cacheRead +
+		float64(tokens.OutputTokens)*m.Output) / 1e6
+}
@@ -0,0 +1,292 @@
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/block/braindump/model"
+)
+
+// Dimensions a usage report can be grouped by
+const (
+	ByAgent   = "agent"
+	ByModel   = "model"
+	ByDir     = "dir"
+	ByDay     = "day"
+	BySession = "session"
+)
+
+// Dimensions lists every supported grouping
+var Dimensions = []string{ByAgent, ByModel, ByDir, ByDay, BySession}
+
+// unknownKey groups usage whose dimension value is missing
+const unknownKey = "(unknown)"
+
+// Usage is aggregated token usage and estimated cost
+type Usage struct {
+	Sessions     int `json:"sessions"`
+	Messages     int `json:"messages"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	// CacheCreationTokens and CacheReadTokens are input tokens written to
+	// and served from the prompt cache, not included in InputTokens
+	CacheCreationTokens int     `json:"cache_creation_tokens"`
+	CacheReadTokens     int     `json:"cache_read_tokens"`
+	TotalTokens         int     `json:"total_tokens"`
+	Cost                float64 `json:"cost"`
+	// UnpricedTokens counts tokens that could not be priced, either because
+	// the model has no price or because only a total was recorded
+	UnpricedTokens int `json:"unpriced_tokens"`
+	// ServerToolUse counts server-side tool requests, such as web searches
+	ServerToolUse map[string]int `json:"server_tool_use,omitempty"`
+}
+
+// Row is the usage for one value of a dimension
+type Row struct {
+	Key string `json:"key"`
+	Usage
+}
+
+// Aggregator rolls up token usage across sessions
+type Aggregator struct {
+	prices *PriceTable
+	totals Usage
+	groups map[string]map[string]*Usage
+}
+
+// record is the usage of a single API response
+type record struct {
+	model     string
+	timestamp time.Time
+	tokens    model.TokenUsage
+}
+
+// NewAggregator creates an aggregator. Prices may be nil, in which case no cost is estimated.
+func NewAggregator(prices *PriceTable) *Aggregator {
+	groups := make(map[string]map[string]*Usage, len(Dimensions))
+	for _, dim := range Dimensions {
+		groups[dim] = make(map[string]*Usage)
+	}
+	return &Aggregator{prices: prices, groups: groups}
+}
+
+// ValidateDimensions checks that every dimension is known
+func ValidateDimensions(dims []string) error {
+	for _, dim := range dims {
+		switch dim {
+		case ByAgent, ByModel, ByDir, ByDay, BySession:
+		default:
+			return fmt.Errorf("unknown dimension %q (expected %s)", dim, strings.Join(Dimensions, ", "))
+		}
+	}
+	return nil
+}
+
+// Add adds a session's token usage, including its subagents
+func (a *Aggregator) Add(session model.Session) {
+	records := usageRecords(session)
+	if len(records) == 0 {
+		return
+	}
+
+	a.totals.Sessions++
+	seen := make(map[string]bool)
+
+	for _, rec := range records {
+		var day string
+		if !rec.timestamp.IsZero() {
+			day = rec.timestamp.UTC().Format("2006-01-02")
+		}
+
+		keys := map[string]string{
+			ByAgent:   session.AgentType,
+			ByModel:   rec.model,
+			ByDir:     session.Metadata.WorkingDir,
+			ByDay:     day,
+			BySession: session.SessionID,
+		}
+
+		usage := a.price(rec)
+		a.totals.add(usage)
+
+		for dim, key := range keys {
+			if key == "" {
+				key = unknownKey
+			}
+
+			group, ok := a.groups[dim][key]
+			if !ok {
+				group = &Usage{}
+				a.groups[dim][key] = group
+			}
+			group.add(usage)
+
+			if !seen[dim+"\x00"+key] {
+				seen[dim+"\x00"+key] = true
+				group.Sessions++
+			}
+		}
+	}
+}
+
+// Totals returns the usage across everything added
+func (a *Aggregator) Totals() Usage {
+	return a.totals
+}
+
+// Report returns the usage grouped by a dimension. Days are listed in
+// chronological order; other dimensions are listed by descending cost, then tokens.
+func (a *Aggregator) Report(dim string) []Row {
+	rows := make([]Row, 0, len(a.groups[dim]))
+	for key, usage := range a.groups[dim] {
+		rows = append(rows, Row{Key: key, Usage: *usage})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if dim != ByDay {
+			if rows[i].Cost != rows[j].Cost {
+				return rows[i].Cost > rows[j].Cost
+			}
+			if rows[i].TotalTokens != rows[j].TotalTokens {
+				return rows[i].TotalTokens > rows[j].TotalTokens
+			}
+		}
+		return rows[i].Key < rows[j].Key
+	})
+
+	return rows
+}
+
+// price converts a usage record into aggregated usage with its estimated cost
+func (a *Aggregator) price(rec record) Usage {
+	tokens := rec.tokens
+
+	// A bare total stands in for counts that were not itemized
+	total := max(tokens.TotalTokens, itemized(tokens))
+
+	usage := Usage{
+		Messages:            1,
+		InputTokens:         tokens.InputTokens,
+		OutputTokens:        tokens.OutputTokens,
+		CacheCreationTokens: tokens.CacheCreationInputTokens,
+		CacheReadTokens:     tokens.CacheReadInputTokens,
+		TotalTokens:         total,
+		ServerToolUse:       tokens.ServerToolUse,
+	}
+
+	price, ok := a.prices.Lookup(rec.model)
+	if !ok || itemized(tokens) == 0 {
+		usage.UnpricedTokens = total
+		return usage
+	}
+
+	usage.Cost = price.cost(tokens)
+	return usage
+}
+
+// add accumulates other into u (except the session count, which is tracked separately)
+func (u *Usage) add(other Usage) {
+	u.Messages += other.Messages
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.TotalTokens += other.TotalTokens
+	u.Cost += other.Cost
+	u.UnpricedTokens += other.UnpricedTokens
+
+	for name, count := range other.ServerToolUse {
+		if u.ServerToolUse == nil {
+			u.ServerToolUse = make(map[string]int)
+		}
+		u.ServerToolUse[name] += count
+	}
+}
+
+// usageRecords extracts one usage record per API response in a session.
+// Claude writes each content block of a response as its own message, all
+// repeating the response's usage, so messages sharing a request ID are
+// counted once (keeping the largest value of each counter). Goose records at
+// most a bare total per message, so a session whose messages have no input or
+// output counts is taken as a single record of its accumulated usage.
+func usageRecords(session model.Session) []record {
+	var records []record
+	byRequest := make(map[string]int)
+
+	collect := func(messages []model.Message) {
+		for _, msg := range messages {
+			if msg.Metadata.Tokens == nil {
+				continue
+			}
+
+			modelName := msg.Metadata.Model
+			if modelName == "" {
+				modelName = session.Metadata.Model
+			}
+
+			rec := record{model: modelName, timestamp: msg.Timestamp, tokens: *msg.Metadata.Tokens}
+			if rec.timestamp.IsZero() {
+				rec.timestamp = session.CreatedAt
+			}
+
+			requestID := msg.Metadata.RequestID
+			if requestID == "" {
+				records = append(records, rec)
+				continue
+			}
+
+			if i, ok := byRequest[requestID]; ok {
+				records[i].tokens = maxTokens(records[i].tokens, rec.tokens)
+				continue
+			}
+			byRequest[requestID] = len(records)
+			records = append(records, rec)
+		}
+	}
+
+	collect(session.Messages)
+	for _, subagent := range session.Subagents {
+		collect(subagent.Messages)
+	}
+
+	if accumulated := session.Metadata.AccumulatedTokens; accumulated != nil && itemized(*accumulated) > 0 &&
+		!slices.ContainsFunc(records, func(rec record) bool { return itemized(rec.tokens) > 0 }) {
+		return []record{{model: session.Metadata.Model, timestamp: session.CreatedAt, tokens: *accumulated}}
+	}
+
+	return records
+}
+
+// itemized returns the tokens counted by kind, which can be priced
+func itemized(tokens model.TokenUsage) int {
+	return tokens.InputTokens + tokens.CacheCreationInputTokens + tokens.CacheReadInputTokens + tokens.OutputTokens
+}
+
+// maxTokens returns the larger of each counter in a and b
+func maxTokens(a, b model.TokenUsage) model.TokenUsage {
+	tokens := model.TokenUsage{
+		InputTokens:              max(a.InputTokens, b.InputTokens),
+		OutputTokens:             max(a.OutputTokens, b.OutputTokens),
+		CacheCreationInputTokens: max(a.CacheCreationInputTokens, b.CacheCreationInputTokens),
+		CacheReadInputTokens:     max(a.CacheReadInputTokens, b.CacheReadInputTokens),
+		TotalTokens:              max(a.TotalTokens, b.TotalTokens),
+		TotalWithCacheTokens:     max(a.TotalWithCacheTokens, b.TotalWithCacheTokens),
+		ServiceTier:              a.ServiceTier,
+	}
+	if tokens.ServiceTier == "" {
+		tokens.ServiceTier = b.ServiceTier
+	}
+
+	for _, counts := range []map[string]int{a.ServerToolUse, b.ServerToolUse} {
+		for name, count := range counts {
+			if tokens.ServerToolUse == nil {
+				tokens.ServerToolUse = make(map[string]int)
+			}
+			tokens.ServerToolUse[name] = max(tokens.ServerToolUse[name], count)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,233 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/block/braindump/model"
+)
+
+func assistant(modelName, requestID string, ts time.Time, input, output int) model.Message {
+	return model.Message{
+		Role:      "assistant",
+		Timestamp: ts,
+		Metadata: model.MessageMetadata{
+			Model:     modelName,
+			RequestID: requestID,
+			Tokens:    &model.TokenUsage{InputTokens: input, OutputTokens: output, TotalTokens: input + output},
+		},
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	day1 := time.Date(2026, 2, 7, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	prices := &PriceTable{
+		Currency: "USD",
+		Models:   map[string]ModelPrice{"claude-sonnet-4-5": {Input: 3, Output: 15}},
+	}
+	agg := NewAggregator(prices)
+
+	agg.Add(model.Session{
+		AgentType: "claude",
+		SessionID: "s1",
+		Metadata:  model.SessionMetadata{WorkingDir: "/proj"},
+		Messages: []model.Message{
+			{Role: "user", Content: []model.ContentBlock{{Type: "text", Text: "hi"}}},
+			// Two content blocks of the same response repeat its usage
+			assistant("claude-sonnet-4-5-20250929", "req-1", day1, 1000, 10),
+			assistant("claude-sonnet-4-5-20250929", "req-1", day1, 1000, 500),
+		},
+		Subagents: []model.Subagent{
+			{AgentID: "a1", Messages: []model.Message{assistant("claude-sonnet-4-5-20250929", "req-2", day2, 2000, 1000)}},
+		},
+	})
+	agg.Add(model.Session{
+		AgentType: "goose",
+		SessionID: "s2",
+		Metadata:  model.SessionMetadata{WorkingDir: "/proj", Model: "gpt-4o"},
+		Messages:  []model.Message{assistant("", "", day2, 100, 50)},
+	})
+	// Sessions without usage are not counted
+	agg.Add(model.Session{AgentType: "goose", SessionID: "s3"})
+
+	totals := agg.Totals()
+	if totals.Sessions != 2 || totals.Messages != 3 {
+		t.Errorf("totals = %d sessions, %d messages, want 2, 3", totals.Sessions, totals.Messages)
+	}
+	if totals.InputTokens != 3100 || totals.OutputTokens != 1550 || totals.TotalTokens != 4650 {
+		t.Errorf("totals tokens = %+v", totals)
+	}
+	wantCost := (3000*3.0 + 1500*15.0) / 1e6
+	if math.Abs(totals.Cost-wantCost) > 1e-9 {
+		t.Errorf("cost = %v, want %v", totals.Cost, wantCost)
+	}
+	if totals.UnpricedTokens != 150 {
+		t.Errorf("unpriced = %d, want 150 (gpt-4o has no price)", totals.UnpricedTokens)
+	}
+
+	models := agg.Report(ByModel)
+	if len(models) != 2 || models[0].Key != "claude-sonnet-4-5-20250929" || models[1].Key != "gpt-4o" {
+		t.Fatalf("model report = %+v", models)
+	}
+
+	dirs := agg.Report(ByDir)
+	if len(dirs) != 1 || dirs[0].Sessions != 2 {
+		t.Errorf("dir report = %+v, want one row with 2 sessions", dirs)
+	}
+
+	days := agg.Report(ByDay)
+	if len(days) != 2 || days[0].Key != "2026-02-07" || days[1].Key != "2026-02-08" {
+		t.Fatalf("day report = %+v", days)
+	}
+	if days[1].Sessions != 2 || days[1].Messages != 2 {
+		t.Errorf("second day = %+v, want 2 sessions, 2 messages", days[1])
+	}
+}
+
+func TestAggregatorCacheTokens(t *testing.T) {
+	prices := &PriceTable{Models: map[string]ModelPrice{
+		"claude-sonnet-4-5": {Input: 3, Output: 15},
+		"claude-opus-4-1":   {Input: 15, Output: 75, CacheWrite: 20, CacheRead: 2},
+	}}
+	agg := NewAggregator(prices)
+
+	cached := func(modelName, requestID string) model.Message {
+		return model.Message{
+			Role: "assistant",
+			Metadata: model.MessageMetadata{
+				Model:     modelName,
+				RequestID: requestID,
+				Tokens: &model.TokenUsage{
+					InputTokens:              10,
+					CacheCreationInputTokens: 1_000_000,
+					CacheReadInputTokens:     1_000_000,
+					OutputTokens:             0,
+					ServerToolUse:            map[string]int{"web_search_requests": 1},
+				},
+			},
+		}
+	}
+
+	agg.Add(model.Session{
+		AgentType: "claude",
+		SessionID: "s1",
+		Messages: []model.Message{
+			cached("claude-sonnet-4-5", "req-1"),
+			cached("claude-sonnet-4-5", "req-1"),
+			cached("claude-opus-4-1", "req-2"),
+		},
+	})
+
+	totals := agg.Totals()
+	if totals.CacheCreationTokens != 2_000_000 || totals.CacheReadTokens != 2_000_000 {
+		t.Errorf("cache tokens = %d/%d, want 2000000/2000000", totals.CacheCreationTokens, totals.CacheReadTokens)
+	}
+	if totals.TotalTokens != 4_000_020 {
+		t.Errorf("total = %d, want 4000020", totals.TotalTokens)
+	}
+	if totals.ServerToolUse["web_search_requests"] != 2 {
+		t.Errorf("server tool use = %v, want 2 web searches", totals.ServerToolUse)
+	}
+
+	// Sonnet uses the default cache multipliers (3.75 + 0.30), Opus its own prices (20 + 2)
+	wantCost := (10*3.0+10*15.0)/1e6 + 3.75 + 0.30 + 20 + 2
+	if math.Abs(totals.Cost-wantCost) > 1e-9 {
+		t.Errorf("cost = %v, want %v", totals.Cost, wantCost)
+	}
+}
+
+func TestAggregatorSessionTokens(t *testing.T) {
+	created := time.Date(2026, 2, 7, 10, 0, 0, 0, time.UTC)
+	prices := &PriceTable{Models: map[string]ModelPrice{"gpt-4o": {Input: 2.5, Output: 10}}}
+	agg := NewAggregator(prices)
+
+	// Goose records a bare total per message and the itemized usage per session
+	bare := model.Message{Role: "assistant", Metadata: model.MessageMetadata{Tokens: &model.TokenUsage{TotalTokens: 700}}}
+	agg.Add(model.Session{
+		AgentType: "goose",
+		SessionID: "s1",
+		CreatedAt: created,
+		Metadata: model.SessionMetadata{
+			WorkingDir:        "/proj",
+			Model:             "gpt-4o",
+			Tokens:            &model.TokenUsage{InputTokens: 400, OutputTokens: 100, TotalTokens: 500},
+			AccumulatedTokens: &model.TokenUsage{InputTokens: 2000, OutputTokens: 500, TotalTokens: 2500},
+		},
+		Messages: []model.Message{{Role: "user"}, bare, bare},
+	})
+	// Only the current context is known, which is not what was spent
+	agg.Add(model.Session{
+		AgentType: "goose",
+		SessionID: "s2",
+		Metadata: model.SessionMetadata{
+			Model:  "gpt-4o",
+			Tokens: &model.TokenUsage{InputTokens: 400, OutputTokens: 100, TotalTokens: 500},
+		},
+	})
+
+	totals := agg.Totals()
+	if totals.Sessions != 1 || totals.Messages != 1 {
+		t.Errorf("totals = %d sessions, %d messages, want 1, 1", totals.Sessions, totals.Messages)
+	}
+	if totals.InputTokens != 2000 || totals.OutputTokens != 500 || totals.TotalTokens != 2500 {
+		t.Errorf("totals tokens = %+v", totals)
+	}
+	wantCost := (2000*2.5 + 500*10.0) / 1e6
+	if math.Abs(totals.Cost-wantCost) > 1e-9 || totals.UnpricedTokens != 0 {
+		t.Errorf("cost = %v, unpriced = %d, want %v, 0", totals.Cost, totals.UnpricedTokens, wantCost)
+	}
+
+	for _, dim := range []string{ByAgent, ByDir, ByModel, ByDay} {
+		rows := agg.Report(dim)
+		if len(rows) != 1 || math.Abs(rows[0].Cost-wantCost) > 1e-9 {
+			t.Errorf("%s report = %+v", dim, rows)
+		}
+	}
+	if days := agg.Report(ByDay); days[0].Key != "2026-02-07" {
+		t.Errorf("day = %q, want the session's creation day", days[0].Key)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	prices := &PriceTable{Models: map[string]ModelPrice{
+		"claude":            {Input: 1},
+		"claude-sonnet-4-5": {Input: 3},
+		"claude-opus-4-1":   {Input: 15},
+	}}
+
+	tests := []struct {
+		model string
+		want  float64
+		ok    bool
+	}{
+		{"claude-sonnet-4-5", 3, true},
+		{"claude-sonnet-4-5-20250929", 3, true},
+		{"claude-haiku-4-5", 1, true},
+		{"gpt-4o", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		price, ok := prices.Lookup(tt.model)
+		if ok != tt.ok || price.Input != tt.want {
+			t.Errorf("Lookup(%q) = %v, %v, want %v, %v", tt.model, price.Input, ok, tt.want, tt.ok)
+		}
+	}
+
+	var none *PriceTable
+	if _, ok := none.Lookup("claude"); ok {
+		t.Error("nil price table should not price anything")
+	}
+}
+
+func TestValidateDimensions(t *testing.T) {
+	if err := ValidateDimensions([]string{ByAgent, BySession}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateDimensions([]string{"week"}); err == nil {
+		t.Error("expected error for unknown dimension")
+	}
+}
@@ -0,0 +1,85 @@
+package timestamp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// layouts are the text formats Parse accepts, tried in order. Layouts without
+// a zone are read as UTC, which is what SQLite's date functions and
+// CURRENT_TIMESTAMP produce.
+var layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	// time.Time.String, which Go SQLite drivers write for time values
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+}
+
+// Parse decodes a timestamp written by an agent or its database: RFC 3339
+// (with or without fractional seconds), SQLite date and time text such as
+// "2006-01-02 15:04:05", or a Unix time in seconds, milliseconds,
+// microseconds or nanoseconds. Times without a zone are UTC.
+func Parse(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("empty timestamp")
+	}
+
+	if t, ok := parseUnix(value); ok {
+		return t, nil
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// parseUnix decodes a numeric Unix time, which may have a fractional part
+func parseUnix(value string) (time.Time, bool) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return Unix(n), true
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || strings.ContainsAny(value, "xXpP") {
+		return time.Time{}, false
+	}
+	// Fractions only make sense on seconds
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+}
+
+// Unix converts a Unix time to UTC, inferring its unit from its magnitude:
+// seconds until the year 5138, then milliseconds, microseconds and nanoseconds
+func Unix(n int64) time.Time {
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+
+	switch {
+	case abs < 1e11:
+		return time.Unix(n, 0).UTC()
+	case abs < 1e14:
+		return time.UnixMilli(n).UTC()
+	case abs < 1e17:
+		return time.UnixMicro(n).UTC()
+	default:
+		return time.Unix(0, n).UTC()
+	}
+}
@@ -0,0 +1,72 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	want := time.Date(2025, 1, 2, 10, 4, 5, 0, time.UTC)
+	withMillis := want.Add(123 * time.Millisecond)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"RFC3339", "2025-01-02T10:04:05Z", want},
+		{"RFC3339Nano", "2025-01-02T10:04:05.123Z", withMillis},
+		{"RFC3339 offset", "2025-01-02T12:04:05+02:00", want},
+		{"SQLite CURRENT_TIMESTAMP", "2025-01-02 10:04:05", want},
+		{"SQLite fractional", "2025-01-02 10:04:05.123", withMillis},
+		{"SQLite offset", "2025-01-02 12:04:05.123+02:00", withMillis},
+		{"SQLite T separator", "2025-01-02T10:04:05", want},
+		{"SQLite minutes", "2025-01-02 10:04", want.Add(-5 * time.Second)},
+		{"SQLite date", "2025-01-02", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"Go time string", "2025-01-02 10:04:05.123 +0000 UTC", withMillis},
+		{"Unix seconds", "1735812245", want},
+		{"Unix fractional seconds", "1735812245.5", want.Add(500 * time.Millisecond)},
+		{"Unix milliseconds", "1735812245123", withMillis},
+		{"Unix microseconds", "1735812245123000", withMillis},
+		{"Unix nanoseconds", "1735812245123000000", withMillis},
+		{"surrounding space", " 2025-01-02 10:04:05\n", want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{"", "yesterday", "2025-13-01", "NaN", "Inf", "0x1p4", "10:04:05"} {
+		if got, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", value, got)
+		}
+	}
+}
+
+func TestUnix(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want time.Time
+	}{
+		{0, time.Unix(0, 0).UTC()},
+		{1717236000, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)},
+		{1717236000000, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)},
+		{-86400, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := Unix(tt.n); !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("Unix(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
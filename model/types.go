@@ -0,0 +1,299 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Output represents the complete output structure
+type Output struct {
+	Version     string    `json:"version"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Sessions    []Session `json:"sessions"`
+	// Diagnostics are the problems found while reading sessions. Without
+	// any, every record in the inputs was read.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// Kinds of diagnostics
+const (
+	// DiagnosticMalformedRecord is a line or row that is not valid JSON
+	DiagnosticMalformedRecord = "malformed_record"
+	// DiagnosticInvalidTimestamp is a timestamp that could not be parsed
+	DiagnosticInvalidTimestamp = "invalid_timestamp"
+	// DiagnosticScanFailed is a database row that could not be read
+	DiagnosticScanFailed = "scan_failed"
+	// DiagnosticReadFailed is a file or session that could not be read at all
+	DiagnosticReadFailed = "read_failed"
+	// DiagnosticNewerSchema is a database written by a newer agent than
+	// braindump knows about, which may have data braindump does not read
+	DiagnosticNewerSchema = "newer_schema"
+)
+
+// Diagnostic is a problem found while reading sessions: data that was
+// skipped, or read only in part
+type Diagnostic struct {
+	Agent string `json:"agent"`
+	File  string `json:"file"`
+	// Line is the 1-based line of a JSONL file
+	Line int `json:"line,omitempty"`
+	// Row identifies a database row, such as a message ID
+	Row       string `json:"row,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Field     string `json:"field,omitempty"`
+	Kind      string `json:"kind"`
+	Message   string `json:"message"`
+}
+
+// Advisory reports whether the diagnostic only warns that data may be
+// missing, such as a newer schema, rather than recording data that was
+// skipped or read in part
+func (d Diagnostic) Advisory() bool {
+	return d.Kind == DiagnosticNewerSchema
+}
+
+// String formats the diagnostic on one line, e.g.
+// "claude: /path/s1.jsonl:12: malformed_record: unexpected end of JSON input"
+func (d Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location += fmt.Sprintf(":%d", d.Line)
+	}
+
+	var details []string
+	if d.SessionID != "" {
+		details = append(details, "session "+d.SessionID)
+	}
+	if d.Row != "" {
+		details = append(details, "row "+d.Row)
+	}
+	if d.Field != "" {
+		details = append(details, "field "+d.Field)
+	}
+	if len(details) > 0 {
+		location += " (" + strings.Join(details, ", ") + ")"
+	}
+
+	return fmt.Sprintf("%s: %s: %s: %s", d.Agent, location, d.Kind, d.Message)
+}
+
+// Session represents a unified agent session
+type Session struct {
+	AgentType string          `json:"agent_type"` // "claude" or "goose"
+	SessionID string          `json:"session_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Metadata  SessionMetadata `json:"metadata"`
+	Messages  []Message       `json:"messages"`
+	Subagents []Subagent      `json:"subagents,omitempty"`
+	// Branches are the paths through a conversation that was rewound or
+	// forked. Linear conversations have none.
+	Branches []Branch `json:"branches,omitempty"`
+	// Events are records other than messages, in the order they were written.
+	// Only read when requested (see --include-events).
+	Events []Event `json:"events,omitempty"`
+}
+
+// Kinds of session events
+const (
+	// EventSystem is a notice from the agent: hook output, API errors and
+	// retries, local command output
+	EventSystem = "system"
+	// EventProgress reports progress of a running tool or hook
+	EventProgress = "progress"
+	// EventFileHistorySnapshot records the file backups taken before edits,
+	// used to rewind file changes
+	EventFileHistorySnapshot = "file_history_snapshot"
+)
+
+// Event is something that happened in a session other than a message
+type Event struct {
+	Kind string `json:"kind"`
+	// Subtype refines the kind, e.g. "api_error", "local_command" or
+	// "stop_hook_summary" for system events, "hook_progress" for progress events
+	Subtype   string    `json:"subtype,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Level is the severity the agent gave the event: "info", "warning", "error", ...
+	Level     string `json:"level,omitempty"`
+	UUID      string `json:"uuid,omitempty"`
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Text      string `json:"text,omitempty"`
+	// Data holds the rest of the record as written by the agent
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// Branch is one path through a conversation tree, from a root message to a leaf
+type Branch struct {
+	LeafUUID string `json:"leaf_uuid"`
+	// Active is set on the branch the conversation continued on
+	Active bool `json:"active,omitempty"`
+	// ForkUUID is the last message an abandoned branch shares with the active branch
+	ForkUUID string `json:"fork_uuid,omitempty"`
+	// MessageUUIDs are the messages on the branch, from the root to the leaf
+	MessageUUIDs []string `json:"message_uuids"`
+}
+
+// SessionMetadata contains session-level metadata
+type SessionMetadata struct {
+	WorkingDir string `json:"working_dir,omitempty"`
+	GitBranch  string `json:"git_branch,omitempty"`
+	Model      string `json:"model,omitempty"`
+	Provider   string `json:"provider,omitempty"`
+	Name       string `json:"name,omitempty"`
+	// Tokens are the token counts the agent recorded for the session's last turn
+	Tokens *TokenUsage `json:"tokens,omitempty"`
+	// AccumulatedTokens are the token counts the agent recorded across the whole session
+	AccumulatedTokens *TokenUsage `json:"accumulated_tokens,omitempty"`
+	// Extensions are the extensions enabled in the session
+	Extensions []Extension `json:"extensions,omitempty"`
+	// Todo is the todo list the agent kept for the session
+	Todo string `json:"todo,omitempty"`
+	// ExtensionState is any other extension state, keyed by "name.version"
+	ExtensionState map[string]any `json:"extension_state,omitempty"`
+	// ScheduleID is the schedule that started the session
+	ScheduleID string `json:"schedule_id,omitempty"`
+	// Recipe is the recipe the session was started from
+	Recipe *Recipe `json:"recipe,omitempty"`
+	// Summaries are the summaries the agent wrote of the conversation
+	Summaries []Summary         `json:"summaries,omitempty"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+// Summary is an agent-written summary of a conversation
+type Summary struct {
+	Summary string `json:"summary"`
+	// LeafUUID is the last message the summary covers
+	LeafUUID string `json:"leaf_uuid,omitempty"`
+}
+
+// Extension is an extension (tool provider) enabled in a session
+type Extension struct {
+	Name string `json:"name"`
+	// Type is how the extension is run, e.g. "builtin", "stdio" or "streamable_http"
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Recipe identifies the recipe a session was started from
+type Recipe struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+	// Parameters are the values the user supplied for the recipe's parameters
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// Message represents a single message in a conversation
+type Message struct {
+	UUID       string          `json:"uuid"`
+	ParentUUID string          `json:"parent_uuid,omitempty"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Role       string          `json:"role"` // "user" or "assistant"
+	Content    []ContentBlock  `json:"content"`
+	Metadata   MessageMetadata `json:"metadata"`
+}
+
+// ContentBlock represents a piece of content (text, reasoning, media, tool use, or tool result)
+type ContentBlock struct {
+	// Type is "text", "thinking", "redacted_thinking", "image", "document", "tool_use",
+	// "tool_result", or a notice: "context_length_exceeded", "summarization_requested",
+	// "compact_boundary"
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	Thinking  string `json:"thinking,omitempty"`  // model reasoning, for "thinking" blocks
+	Signature string `json:"signature,omitempty"` // verifies a "thinking" block was produced by the model
+	// Data is inline block data: base64 media or plain text for "image" and
+	// "document" blocks, or encrypted reasoning for "redacted_thinking" blocks
+	Data string `json:"data,omitempty"`
+	// MediaType is the MIME type of an "image" or "document" block
+	MediaType string `json:"media_type,omitempty"`
+	// SourceType is where media comes from: "base64", "text", "url" or "file"
+	SourceType string `json:"source_type,omitempty"`
+	URL        string `json:"url,omitempty"`     // media location, for "url" sources
+	FileID     string `json:"file_id,omitempty"` // uploaded file, for "file" sources
+	Title      string `json:"title,omitempty"`   // document title
+	// Path is the file media was extracted to, replacing Data (see --extract-media)
+	Path      string         `json:"path,omitempty"`
+	ToolName  string         `json:"tool_name,omitempty"`
+	ToolInput map[string]any `json:"tool_input,omitempty"`
+	ToolUseID string         `json:"tool_use_id,omitempty"`
+	// ToolContent is the text of a tool result, with multiple parts joined by newlines
+	ToolContent string `json:"tool_content,omitempty"`
+	// Parts are the typed items (text, image, document) of a tool result whose
+	// content was a list. Results with plain string content only set ToolContent.
+	Parts []ContentBlock `json:"parts,omitempty"`
+	// Audience lists who an item was meant for, "user" and/or "assistant",
+	// as annotated by the MCP tool that returned it. Empty means everyone.
+	Audience []string `json:"audience,omitempty"`
+	// IsError reports whether a tool result is an error
+	IsError bool `json:"is_error,omitempty"`
+	// Compaction describes a "compact_boundary", where earlier context was
+	// replaced by a summary
+	Compaction *Compaction `json:"compaction,omitempty"`
+}
+
+// Compaction describes a point where the conversation was compacted
+type Compaction struct {
+	// Trigger is "auto" when the context window filled up, or "manual" (/compact)
+	Trigger string `json:"trigger,omitempty"`
+	// PreTokens is the size of the context, in tokens, before compaction
+	PreTokens int `json:"pre_tokens,omitempty"`
+}
+
+// MessageMetadata contains message-level metadata
+type MessageMetadata struct {
+	IsSidechain bool        `json:"is_sidechain,omitempty"`
+	AgentID     string      `json:"agent_id,omitempty"`
+	Tokens      *TokenUsage `json:"tokens"`
+	Model       string      `json:"model,omitempty"`
+	RequestID   string      `json:"request_id,omitempty"`
+	// IsCompactSummary is set on the message that carries the summary of
+	// the context lost at a compact_boundary
+	IsCompactSummary bool `json:"is_compact_summary,omitempty"`
+	// UserVisible is false for messages the user never saw, such as context
+	// the agent injected. Nil means the message was visible.
+	UserVisible *bool `json:"user_visible,omitempty"`
+	// AgentVisible is false for messages not sent to the model, such as
+	// notices shown only to the user. Nil means the message was visible.
+	AgentVisible *bool          `json:"agent_visible,omitempty"`
+	Extra        map[string]any `json:"extra,omitempty"`
+}
+
+// VisibleToUser reports whether the message was shown to the user
+func (m MessageMetadata) VisibleToUser() bool {
+	return m.UserVisible == nil || *m.UserVisible
+}
+
+// VisibleToAgent reports whether the message was sent to the model
+func (m MessageMetadata) VisibleToAgent() bool {
+	return m.AgentVisible == nil || *m.AgentVisible
+}
+
+// TokenUsage represents token usage statistics
+type TokenUsage struct {
+	InputTokens  int `json:"input_tokens,omitempty"` // uncached input tokens
+	OutputTokens int `json:"output_tokens,omitempty"`
+	// CacheCreationInputTokens are input tokens written to the prompt cache
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	// CacheReadInputTokens are input tokens served from the prompt cache
+	CacheReadInputTokens int `json:"cache_read_input_tokens,omitempty"`
+	// TotalTokens is uncached input plus output tokens
+	TotalTokens int `json:"total_tokens,omitempty"`
+	// TotalWithCacheTokens is all input (uncached and cached) plus output tokens,
+	// set when any input was written to or read from the cache
+	TotalWithCacheTokens int    `json:"total_with_cache_tokens,omitempty"`
+	ServiceTier          string `json:"service_tier,omitempty"`
+	// ServerToolUse counts server-side tool requests, e.g. "web_search_requests"
+	ServerToolUse map[string]int `json:"server_tool_use,omitempty"`
+}
+
+// Subagent represents a subagent conversation
+type Subagent struct {
+	AgentID   string    `json:"agent_id"`
+	Slug      string    `json:"slug,omitempty"`
+	ToolUseID string    `json:"tool_use_id,omitempty"` // Task tool_use that spawned the subagent
+	Messages  []Message `json:"messages"`
+	// Events are the subagent's records other than messages, when events are read
+	Events []Event `json:"events,omitempty"`
+}
@@ -0,0 +1,141 @@
+package source
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+
+	"github.com/block/braindump/cache"
+	"github.com/block/braindump/diagnostics"
+	"github.com/block/braindump/model"
+	"github.com/spf13/pflag"
+)
+
+// Source reads session histories for a single agent type
+type Source interface {
+	// Name returns the agent type this source produces (e.g. "claude")
+	Name() string
+	// Detect reports whether the agent's data is present on this machine
+	Detect() bool
+	// Sessions yields sessions one at a time as they are parsed.
+	// A non-nil error is yielded at most once and ends the sequence.
+	Sessions() iter.Seq2[model.Session, error]
+}
+
+// HintError is implemented by errors a source yields when the user can do
+// something about them, such as retrying or changing one of its flags
+type HintError interface {
+	error
+	// Hint suggests how to resolve the error
+	Hint() string
+}
+
+// ReadAll collects every session from src into a slice
+func ReadAll(src Source) ([]model.Session, error) {
+	var sessions []model.Session
+	for session, err := range src.Sessions() {
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// Config carries the settings shared by every source to a source factory.
+// Settings of one source, such as where it reads from, come from its own
+// flags (see Registry.RegisterFlags).
+type Config struct {
+	// Jobs bounds how many inputs the source may parse concurrently.
+	// Zero lets the source choose.
+	Jobs int
+	// Cache stores parsed data between runs. Nil disables caching.
+	Cache *cache.Cache
+	// Diagnostics collects records the source skipped or could not fully
+	// read. Nil discards them.
+	Diagnostics *diagnostics.Collector
+}
+
+// Factory creates a Source from its configuration
+type Factory func(cfg Config) (Source, error)
+
+// Flags adds a source's own command-line flags to a flag set. The source's
+// factory reads the values they were parsed into.
+type Flags func(fs *pflag.FlagSet)
+
+// Registry holds the set of known sources, keyed by agent name
+type Registry struct {
+	factories map[string]Factory
+	flags     map[string]Flags
+}
+
+// NewRegistry creates an empty source registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory), flags: make(map[string]Flags)}
+}
+
+// Register makes a source available under the given name.
+// It panics if the name is empty, the factory is nil, or the name is already registered.
+func (r *Registry) Register(name string, factory Factory) {
+	if name == "" {
+		panic("source: Register called with empty name")
+	}
+	if factory == nil {
+		panic("source: Register factory is nil for " + name)
+	}
+	if _, dup := r.factories[name]; dup {
+		panic("source: Register called twice for " + name)
+	}
+	r.factories[name] = factory
+}
+
+// RegisterFlags sets the command-line flags of the source registered under name.
+// It panics if no source is registered under name or flags is nil.
+func (r *Registry) RegisterFlags(name string, flags Flags) {
+	if !r.Has(name) {
+		panic("source: RegisterFlags called for unregistered source " + name)
+	}
+	if flags == nil {
+		panic("source: RegisterFlags flags is nil for " + name)
+	}
+	r.flags[name] = flags
+}
+
+// AddFlags adds the command-line flags of every registered source to fs
+func (r *Registry) AddFlags(fs *pflag.FlagSet) {
+	for _, name := range r.Names() {
+		if flags, ok := r.flags[name]; ok {
+			flags(fs)
+		}
+	}
+}
+
+// Has reports whether a source is registered under name
+func (r *Registry) Has(name string) bool {
+	_, ok := r.factories[name]
+	return ok
+}
+
+// Names returns the registered source names in sorted order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open creates the source registered under name
+func (r *Registry) Open(name string, cfg Config) (Source, error) {
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown source %q", name)
+	}
+
+	src, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s source: %w", name, err)
+	}
+	return src, nil
+}
@@ -0,0 +1,131 @@
+package source
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"testing"
+
+	"github.com/block/braindump/model"
+	"github.com/spf13/pflag"
+)
+
+type fakeSource struct {
+	name  string
+	count int
+	err   error
+}
+
+func (f *fakeSource) Name() string { return f.name }
+
+func (f *fakeSource) Detect() bool { return true }
+
+func (f *fakeSource) Sessions() iter.Seq2[model.Session, error] {
+	return func(yield func(model.Session, error) bool) {
+		for i := range f.count {
+			if !yield(model.Session{AgentType: f.name, SessionID: fmt.Sprint(i)}, nil) {
+				return
+			}
+		}
+		if f.err != nil {
+			yield(model.Session{}, f.err)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("zeta", func(Config) (Source, error) { return &fakeSource{name: "zeta"}, nil })
+	registry.Register("alpha", func(Config) (Source, error) { return &fakeSource{name: "alpha"}, nil })
+
+	if got, want := registry.Names(), []string{"alpha", "zeta"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names: got %v, want %v", got, want)
+	}
+
+	if !registry.Has("alpha") {
+		t.Error("Has(alpha): got false, want true")
+	}
+
+	if registry.Has("missing") {
+		t.Error("Has(missing): got true, want false")
+	}
+
+	src, err := registry.Open("zeta", Config{})
+	if err != nil {
+		t.Fatalf("Open(zeta): %v", err)
+	}
+
+	if src.Name() != "zeta" {
+		t.Errorf("Name: got %q, want %q", src.Name(), "zeta")
+	}
+
+	if _, err := registry.Open("missing", Config{}); err == nil {
+		t.Error("Open(missing): expected error")
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	registry := NewRegistry()
+	factory := func(Config) (Source, error) { return &fakeSource{name: "dup"}, nil }
+	registry.Register("dup", factory)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate registration")
+		}
+	}()
+	registry.Register("dup", factory)
+}
+
+func TestRegistryFlags(t *testing.T) {
+	registry := NewRegistry()
+
+	var dirs []string
+	registry.Register("fake", func(Config) (Source, error) {
+		return &fakeSource{name: "fake", count: len(dirs)}, nil
+	})
+	registry.RegisterFlags("fake", func(fs *pflag.FlagSet) {
+		fs.StringSliceVar(&dirs, "fake-dir", nil, "Fake data directory")
+	})
+	registry.Register("plain", func(Config) (Source, error) { return &fakeSource{name: "plain"}, nil })
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	registry.AddFlags(fs)
+	if err := fs.Parse([]string{"--fake-dir", "a", "--fake-dir", "b"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	src, err := registry.Open("fake", Config{})
+	if err != nil {
+		t.Fatalf("Open(fake): %v", err)
+	}
+	sessions, err := ReadAll(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Errorf("factory saw %d directories, want 2", len(sessions))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic registering flags for an unknown source")
+		}
+	}()
+	registry.RegisterFlags("missing", func(*pflag.FlagSet) {})
+}
+
+func TestReadAll(t *testing.T) {
+	sessions, err := ReadAll(&fakeSource{name: "fake", count: 3})
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	if len(sessions) != 3 {
+		t.Errorf("Expected 3 sessions, got %d", len(sessions))
+	}
+
+	if _, err := ReadAll(&fakeSource{name: "fake", count: 1, err: fmt.Errorf("boom")}); err == nil {
+		t.Error("ReadAll: expected error")
+	}
+}